@@ -0,0 +1,40 @@
+package deque
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkDeque_Oscillate pushes and pops elements,
+// so the length of deque crosses the chunk bound.
+func BenchmarkDeque_Oscillate(b *testing.B) {
+	var pool ChunkPool[int]
+
+	impls := []struct {
+		name  string
+		setup func(d *Deque[int])
+	}{
+		{"nospare", func(d *Deque[int]) { d.SetSpareLimit(0) }},
+		{"default", func(d *Deque[int]) {}},
+		{"pool", func(d *Deque[int]) { d.SetSpareLimit(0); d.SetChunkPool(&pool) }},
+	}
+
+	for _, impl := range impls {
+		for _, burst := range []int{16, 4096} {
+			b.Run(fmt.Sprintf("%s/burst=%d", impl.name, burst), func(b *testing.B) {
+				var d Deque[int]
+				impl.setup(&d)
+
+				b.ReportAllocs()
+				for b.Loop() {
+					for i := range burst {
+						d.PushBack(i)
+					}
+					for range burst {
+						d.PopFront()
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package deque
+
+import (
+	"context"
+
+	"github.com/nikmy/algo/syncx"
+)
+
+// NewBlocking returns Blocking with given capacity.
+// Non-positive capacity means unbounded deque.
+func NewBlocking[T any](capacity int) *Blocking[T] {
+	b := &Blocking[T]{capacity: capacity}
+	b.notEmpty = syncx.NewCond(&b.lock)
+	b.notFull = syncx.NewCond(&b.lock)
+	return b
+}
+
+// Blocking is deque, which is safe for concurrent use. Push
+// to the full deque and pop from the empty one wait, until
+// the operation is possible or the context is done.
+type Blocking[T any] struct {
+	lock     syncx.Mutex
+	notEmpty *syncx.Cond
+	notFull  *syncx.Cond
+	items    Deque[T]
+	capacity int
+}
+
+func (b *Blocking[T]) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.items.Len()
+}
+
+// PushBack appends x, waiting for free space. It returns
+// context error, if ctx is done before x is appended.
+func (b *Blocking[T]) PushBack(ctx context.Context, x T) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := b.wait(ctx, b.notFull, b.hasSpace); err != nil {
+		return err
+	}
+
+	b.push(x)
+	return nil
+}
+
+// TryPushBack appends x, if the deque is not full.
+func (b *Blocking[T]) TryPushBack(x T) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.hasSpace() {
+		return false
+	}
+
+	b.push(x)
+	return true
+}
+
+// PopFront removes the first element, waiting for it.
+// It returns context error, if ctx is done before.
+func (b *Blocking[T]) PopFront(ctx context.Context) (T, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := b.wait(ctx, b.notEmpty, b.hasItems); err != nil {
+		return *new(T), err
+	}
+
+	return b.pop(), nil
+}
+
+// TryPopFront removes the first element, if any.
+func (b *Blocking[T]) TryPopFront() (T, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.hasItems() {
+		return *new(T), false
+	}
+
+	return b.pop(), true
+}
+
+func (b *Blocking[T]) push(x T) {
+	b.items.PushBack(x)
+	b.notEmpty.Signal()
+}
+
+func (b *Blocking[T]) pop() T {
+	x := b.items.PopFront()
+	b.notFull.Signal()
+	return x
+}
+
+func (b *Blocking[T]) hasSpace() bool {
+	return b.capacity <= 0 || b.items.Len() < b.capacity
+}
+
+func (b *Blocking[T]) hasItems() bool {
+	return !b.items.IsEmpty()
+}
+
+// wait waits on cond until ready or ctx is done. Lock must be
+// held. Cancellation wakes all waiters of cond under the lock,
+// so it can't happen between the check of ctx and Wait.
+func (b *Blocking[T]) wait(ctx context.Context, cond *syncx.Cond, ready func() bool) error {
+	if ready() {
+		return nil
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		cond.Broadcast()
+	})
+	defer stop()
+
+	for !ready() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		cond.Wait()
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package deque
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikmy/algo/syncx/atomx"
+	"github.com/nikmy/algo/testx/faulty"
+	"github.com/nikmy/algo/testx/synctest"
+)
+
+func TestBlocking_Safety(t *testing.T) {
+	b := NewBlocking[int](4)
+
+	var pushed, popped atomx.Int64
+	produce := synctest.Operation{
+		Runner: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+			if b.PushBack(ctx, 1) == nil {
+				pushed.Add(1)
+			}
+		},
+		Actors: 2,
+	}
+
+	consume := synctest.Operation{
+		Runner: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+			if x, err := b.PopFront(ctx); err == nil {
+				popped.Add(int64(x))
+			}
+		},
+		Actors: 2,
+	}
+
+	c := faulty.NewController(t, 42)
+	c.SetFaultProbability(0.3)
+
+	synctest.Stress(t, c.FaultInjector, 10_000, produce, consume)
+
+	for {
+		x, ok := b.TryPopFront()
+		if !ok {
+			break
+		}
+		popped.Add(int64(x))
+	}
+	require.Equal(t, pushed.Load(), popped.Load())
+}
+
+func TestBlocking_Cancel(t *testing.T) {
+	b := NewBlocking[int](1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := b.PopFront(ctx)
+		done <- err
+	}()
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	require.True(t, b.TryPushBack(1))
+	require.False(t, b.TryPushBack(2))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, b.PushBack(ctx, 2), context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.TryPopFront()
+	}()
+	require.NoError(t, b.PushBack(context.Background(), 3))
+
+	x, err := b.PopFront(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, x)
+	require.Equal(t, 0, b.Len())
+}
@@ -1,159 +1,399 @@
 package deque
 
-import "unsafe"
-
+import (
+	"iter"
+	"math/bits"
+	"unsafe"
+)
+
+// Deque is double-ended queue of chunks with O(1) random
+// access. Chunks of ~4KB are referenced by the slice, which
+// is recentered or grown, when either end reaches its bound,
+// and shrinks, when it is mostly empty.
+//
+// Released chunks are kept in the free list, so deque doesn't
+// allocate, while its length oscillates around the chunk bound.
+// Chunks beyond the limit of the free list go to the ChunkPool,
+// if it is set, or to the garbage collector.
+//
+// Zero value is an empty deque ready to use.
 type Deque[T any] struct {
-	bufHead *buffer[T]
-	bufTail *buffer[T]
+	chunks []*chunk[T]
+	head   int
+	size   int
+
+	// spare is the free list, its capacity is the limit
+	spare []*chunk[T]
+	pool  *ChunkPool[T]
 }
 
-func (d *Deque[T]) Clear() {
-	d.bufTail = d.bufHead
-	d.bufHead.head = 0
-	d.bufHead.tail = 0
+type chunk[T any] []T
+
+const (
+	defaultSpareChunks = 2
+
+	// minShrinkChunks is minimal length of the slice
+	// of chunks, which shrinks automatically.
+	minShrinkChunks = 16
+)
+
+// SetSpareLimit sets maximal number of chunks in the free list.
+func (d *Deque[T]) SetSpareLimit(n int) {
+	spare := d.spare
+	d.spare = make([]*chunk[T], 0, max(0, n))
+	for _, c := range spare {
+		d.recycle(c)
+	}
 }
 
-func (d *Deque[T]) Len() int {
-	size := 0
-	for i := d.bufHead; i != nil; i = i.next {
-		size += i.size
+// SetChunkPool makes deque share chunks via pool, when
+// its free list is full.
+func (d *Deque[T]) SetChunkPool(pool *ChunkPool[T]) {
+	d.pool = pool
+}
+
+// Shrink releases all memory, which is not used
+// by elements, including chunks in the free list.
+func (d *Deque[T]) Shrink() {
+	for i, c := range d.spare {
+		if d.pool != nil {
+			d.pool.put(c)
+		}
+		d.spare[i] = nil
 	}
+	d.spare = d.spare[:0]
 
-	return size
+	if d.size == 0 {
+		d.chunks = nil
+		d.head = 0
+		return
+	}
+	d.remap(d.used())
+}
+
+func (d *Deque[T]) Clear() {
+	for i, c := range d.chunks {
+		if c != nil {
+			clear(*c)
+			d.recycle(c)
+			d.chunks[i] = nil
+		}
+	}
+	d.head = 0
+	d.size = 0
+}
+
+func (d *Deque[T]) Len() int {
+	return d.size
 }
 
 func (d *Deque[T]) IsEmpty() bool {
-	return !d.bufHead.isEmpty()
+	return d.size == 0
 }
 
 func (d *Deque[T]) PushFront(x T) {
-	if d.bufHead == nil {
-		d.bufHead = newBuffer[T]()
-		d.bufTail = d.bufHead
+	if d.head == 0 {
+		d.grow()
 	}
 
-	if d.bufHead.isFull() {
-		old := d.bufHead
-		d.bufHead = newBuffer[T]()
-		d.bufHead.next = old
-		old.prev = d.bufHead
+	d.head--
+	*d.ref(d.head) = x
+	d.size++
+}
+
+func (d *Deque[T]) PushBack(x T) {
+	if d.head+d.size == len(d.chunks)<<chunkShift[T]() {
+		d.grow()
 	}
 
-	d.bufHead.pushFront(x)
+	*d.ref(d.head + d.size) = x
+	d.size++
 }
 
-func (d *Deque[T]) PushBack(x T) {
-	if d.bufHead == nil {
-		d.bufHead = newBuffer[T]()
-		d.bufTail = d.bufHead
+func (d *Deque[T]) PopFront() T {
+	x, ok := d.TryPopFront()
+	if !ok {
+		panic("pop of empty deque")
 	}
+	return x
+}
 
-	if d.bufTail.isFull() {
-		old := d.bufTail
-		d.bufTail = newBuffer[T]()
-		old.next = d.bufTail
-		d.bufTail.prev = old
+func (d *Deque[T]) PopBack() T {
+	x, ok := d.TryPopBack()
+	if !ok {
+		panic("pop of empty deque")
+	}
+	return x
+}
+
+func (d *Deque[T]) TryPopFront() (T, bool) {
+	if d.size == 0 {
+		return *new(T), false
 	}
 
-	d.bufTail.pushBack(x)
+	p := d.ref(d.head)
+	pop := *p
+	*p = *new(T)
+	d.head++
+	d.size--
+
+	if d.head&chunkMask[T]() == 0 || d.size == 0 {
+		d.release((d.head - 1) >> chunkShift[T]())
+	}
+	return pop, true
 }
 
-func (d *Deque[T]) PopFront() T {
-	if d.bufHead.isEmpty() {
-		panic("pop of empty deque")
+func (d *Deque[T]) TryPopBack() (T, bool) {
+	if d.size == 0 {
+		return *new(T), false
 	}
 
-	pop := d.bufHead.popFront()
-	if d.bufHead != d.bufTail && d.bufHead.isEmpty() {
-		d.bufHead = d.bufHead.next
-		d.bufHead.prev = nil
+	d.size--
+	tail := d.head + d.size
+	p := d.ref(tail)
+	pop := *p
+	*p = *new(T)
 
+	if tail&chunkMask[T]() == 0 || d.size == 0 {
+		d.release(tail >> chunkShift[T]())
 	}
+	return pop, true
+}
 
-	return pop
+// Front returns the first element. It panics, if deque is empty.
+func (d *Deque[T]) Front() T {
+	return d.At(0)
 }
 
-func (d *Deque[T]) PopBack() T {
-	if d.bufTail.isEmpty() {
-		panic("pop of empty deque")
+// Back returns the last element. It panics, if deque is empty.
+func (d *Deque[T]) Back() T {
+	return d.At(d.size - 1)
+}
+
+// At returns i-th element from the front.
+func (d *Deque[T]) At(i int) T {
+	d.check(i)
+	return *d.ref(d.head + i)
+}
+
+// Set replaces i-th element from the front.
+func (d *Deque[T]) Set(i int, x T) {
+	d.check(i)
+	*d.ref(d.head + i) = x
+}
+
+// All iterates over elements with their indices from the front.
+func (d *Deque[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := range d.size {
+			if !yield(i, *d.ref(d.head + i)) {
+				return
+			}
+		}
+	}
+}
+
+// Backward iterates over elements with their indices from the back.
+func (d *Deque[T]) Backward() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := d.size - 1; i >= 0; i-- {
+			if !yield(i, *d.ref(d.head + i)) {
+				return
+			}
+		}
 	}
+}
 
-	pop := d.bufTail.popBack()
-	if d.bufTail != d.bufHead && d.bufTail.isEmpty() {
-		d.bufTail = d.bufHead.prev
-		d.bufTail.next = nil
+// Insert inserts x, so it becomes i-th element. Elements
+// of the shorter side are shifted, so it takes O(min(i, n-i)).
+func (d *Deque[T]) Insert(i int, x T) {
+	if i < 0 || i > d.size {
+		panic("deque index out of range")
 	}
 
-	return pop
+	if i < d.size/2 {
+		d.PushFront(x)
+		for j := 0; j < i; j++ {
+			d.swap(j, j+1)
+		}
+		return
+	}
+
+	d.PushBack(x)
+	for j := d.size - 1; j > i; j-- {
+		d.swap(j, j-1)
+	}
 }
 
-const bufferBytesLen = 4096
+// Remove removes and returns i-th element. Elements
+// of the shorter side are shifted, so it takes O(min(i, n-i)).
+func (d *Deque[T]) Remove(i int) T {
+	d.check(i)
+
+	if i < d.size/2 {
+		for j := i; j > 0; j-- {
+			d.swap(j, j-1)
+		}
+		return d.PopFront()
+	}
+
+	for j := i; j < d.size-1; j++ {
+		d.swap(j, j+1)
+	}
+	return d.PopBack()
+}
+
+// Rotate moves n elements from the back to the front,
+// or -n elements from the front to the back, if n < 0.
+func (d *Deque[T]) Rotate(n int) {
+	if d.size <= 1 {
+		return
+	}
+
+	n %= d.size
+	if n < 0 {
+		n += d.size
+	}
+
+	if n <= d.size/2 {
+		for range n {
+			d.PushFront(d.PopBack())
+		}
+		return
+	}
+
+	for range d.size - n {
+		d.PushBack(d.PopFront())
+	}
+}
 
-func newBuffer[T any]() *buffer[T] {
-	tSize, tAlign := unsafe.Sizeof(*new(T)), unsafe.Alignof(*new(T))
-	if r := tSize % tAlign; r > 0 {
-		tSize += tAlign - r
+// ToSlice returns a copy of elements in order from the front.
+func (d *Deque[T]) ToSlice() []T {
+	s := make([]T, 0, d.size)
+	for _, x := range d.All() {
+		s = append(s, x)
 	}
+	return s
+}
 
-	targetLen := bufferBytesLen / tSize
-	return &buffer[T]{constBuffer: constBuffer[T]{data: make([]T, targetLen)}}
+func (d *Deque[T]) check(i int) {
+	if i < 0 || i >= d.size {
+		panic("deque index out of range")
+	}
 }
 
-type buffer[T any] struct {
-	constBuffer[T]
-	next *buffer[T]
-	prev *buffer[T]
+func (d *Deque[T]) swap(i, j int) {
+	a, b := d.ref(d.head+i), d.ref(d.head+j)
+	*a, *b = *b, *a
 }
 
-func (b *buffer[T]) pushBack(x T) {
-	b.data[b.tail] = x
-	b.tail = b.idx(b.tail + 1)
-	b.size++
+// ref returns pointer to the element at position p,
+// counting from the beginning of the first chunk.
+func (d *Deque[T]) ref(p int) *T {
+	c := &d.chunks[p>>chunkShift[T]()]
+	if *c == nil {
+		*c = d.newChunk()
+	}
+	return &(**c)[p&chunkMask[T]()]
 }
 
-func (b *buffer[T]) pushFront(x T) {
-	b.head = b.idx(b.head - 1)
-	b.data[b.head] = x
-	b.size++
+// release recycles the chunk, which has no elements, and
+// shrinks the slice of chunks, if it is used by a quarter.
+func (d *Deque[T]) release(i int) {
+	d.recycle(d.chunks[i])
+	d.chunks[i] = nil
+
+	if used := d.used(); len(d.chunks) >= minShrinkChunks && 4*(used+1) <= len(d.chunks) {
+		d.remap(2 * (used + 1))
+	}
 }
 
-func (b *buffer[T]) popFront() T {
-	pop := b.front()
-	b.head = b.idx(b.head + 1)
-	b.size--
-	return pop
+func (d *Deque[T]) newChunk() *chunk[T] {
+	if n := len(d.spare); n > 0 {
+		c := d.spare[n-1]
+		d.spare[n-1] = nil
+		d.spare = d.spare[:n-1]
+		return c
+	}
+
+	if d.pool != nil {
+		return d.pool.get()
+	}
+	return newChunk[T]()
 }
 
-func (b *buffer[T]) popBack() T {
-	pop := b.back()
-	b.tail = b.idx(b.tail - 1)
-	b.size--
-	return pop
+func (d *Deque[T]) recycle(c *chunk[T]) {
+	if d.spare == nil {
+		d.spare = make([]*chunk[T], 0, defaultSpareChunks)
+	}
+
+	if len(d.spare) < cap(d.spare) {
+		d.spare = append(d.spare, c)
+	} else if d.pool != nil {
+		d.pool.put(c)
+	}
 }
 
-type constBuffer[T any] struct {
-	data []T
-	head int
-	tail int
-	size int
+// used returns number of chunks with elements.
+func (d *Deque[T]) used() int {
+	if d.size == 0 {
+		return 0
+	}
+
+	shift := chunkShift[T]()
+	return (d.head+d.size-1)>>shift - d.head>>shift + 1
 }
 
-func (b constBuffer[T]) front() T {
-	return b.data[b.head]
+// grow makes room for one chunk at both ends. Used chunks
+// are moved to the middle of the slice, which is doubled,
+// if they occupy more than a half of it.
+func (d *Deque[T]) grow() {
+	used := d.used()
+	d.remap(max(len(d.chunks), 2*(used+1)))
 }
 
-func (b constBuffer[T]) back() T {
-	return b.data[b.idx(b.tail-1)]
+// remap moves used chunks to the middle
+// of the slice of chunks of given length.
+func (d *Deque[T]) remap(length int) {
+	shift := chunkShift[T]()
+	first := d.head >> shift
+	used := d.used()
+
+	chunks := d.chunks
+	if length != len(chunks) {
+		chunks = make([]*chunk[T], length)
+	}
+
+	off := (len(chunks) - used) / 2
+	copy(chunks[off:], d.chunks[first:first+used])
+	if off < first {
+		clear(d.chunks[max(off+used, first) : first+used])
+	} else {
+		clear(d.chunks[first:min(off, first+used)])
+	}
+
+	d.chunks = chunks
+	d.head = off<<shift + d.head&chunkMask[T]()
+	if d.size == 0 {
+		d.head = off<<shift + 1<<shift/2
+	}
 }
 
-func (b constBuffer[T]) isFull() bool {
-	return b.size == len(b.data)
+const chunkBytesLen = 4096
+
+func newChunk[T any]() *chunk[T] {
+	c := make(chunk[T], 1<<chunkShift[T]())
+	return &c
 }
 
-func (b constBuffer[T]) isEmpty() bool {
-	return b.size == 0
+// chunkShift is log2 of elements in chunk. Chunk length is
+// power of two, so positions are split into chunk and offset
+// without division.
+func chunkShift[T any]() int {
+	size := max(1, unsafe.Sizeof(*new(T)))
+	return max(0, bits.Len(uint(chunkBytesLen/size))-1)
 }
 
-func (b constBuffer[T]) idx(i int) int {
-	return i % len(b.data)
+func chunkMask[T any]() int {
+	return 1<<chunkShift[T]() - 1
 }
@@ -0,0 +1,190 @@
+package deque
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeque(t *testing.T) {
+	var d Deque[int]
+	var et []int
+
+	rnd := rand.New(rand.NewSource(42))
+	for i := range 50_000 {
+		switch rnd.Intn(9) {
+		case 0, 1:
+			d.PushBack(i)
+			et = append(et, i)
+		case 2, 3:
+			d.PushFront(i)
+			et = slices.Insert(et, 0, i)
+		case 4:
+			if len(et) > 0 {
+				require.Equal(t, et[0], d.PopFront())
+				et = et[1:]
+			}
+		case 5:
+			if len(et) > 0 {
+				require.Equal(t, et[len(et)-1], d.PopBack())
+				et = et[:len(et)-1]
+			}
+		case 6:
+			j := rnd.Intn(len(et) + 1)
+			d.Insert(j, i)
+			et = slices.Insert(et, j, i)
+		case 7:
+			if len(et) > 0 {
+				j := rnd.Intn(len(et))
+				require.Equal(t, et[j], d.Remove(j))
+				et = slices.Delete(et, j, j+1)
+			}
+		case 8:
+			if len(et) > 0 {
+				j := rnd.Intn(len(et))
+				d.Set(j, -i)
+				et[j] = -i
+			}
+		}
+
+		require.Equal(t, len(et), d.Len())
+		if len(et) > 0 {
+			j := rnd.Intn(len(et))
+			require.Equal(t, et[j], d.At(j))
+			require.Equal(t, et[0], d.Front())
+			require.Equal(t, et[len(et)-1], d.Back())
+		}
+	}
+
+	require.Equal(t, et, d.ToSlice())
+}
+
+func TestDeque_Iterators(t *testing.T) {
+	var d Deque[int]
+	for i := range 5000 {
+		d.PushBack(i)
+	}
+
+	for i, x := range d.All() {
+		require.Equal(t, i, x)
+	}
+
+	next := d.Len() - 1
+	for i, x := range d.Backward() {
+		require.Equal(t, next, i)
+		require.Equal(t, i, x)
+		next--
+	}
+	require.Equal(t, -1, next)
+}
+
+func TestDeque_Rotate(t *testing.T) {
+	var d Deque[int]
+	for i := range 10 {
+		d.PushBack(i)
+	}
+
+	d.Rotate(3)
+	require.Equal(t, []int{7, 8, 9, 0, 1, 2, 3, 4, 5, 6}, d.ToSlice())
+	d.Rotate(-3)
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, d.ToSlice())
+	d.Rotate(-12)
+	require.Equal(t, []int{2, 3, 4, 5, 6, 7, 8, 9, 0, 1}, d.ToSlice())
+	d.Rotate(8)
+	require.Equal(t, []int{4, 5, 6, 7, 8, 9, 0, 1, 2, 3}, d.ToSlice())
+}
+
+func TestDeque_Empty(t *testing.T) {
+	var d Deque[string]
+	require.True(t, d.IsEmpty())
+	require.Panics(t, func() { d.PopFront() })
+	require.Panics(t, func() { d.PopBack() })
+	require.Panics(t, func() { d.Front() })
+	_, ok := d.TryPopFront()
+	require.False(t, ok)
+	_, ok = d.TryPopBack()
+	require.False(t, ok)
+
+	d.PushFront("a")
+	require.False(t, d.IsEmpty())
+	d.Clear()
+	require.True(t, d.IsEmpty())
+	require.Empty(t, d.ToSlice())
+
+	d.PushBack("b")
+	require.Equal(t, "b", d.PopFront())
+}
+
+func TestDeque_Recycle(t *testing.T) {
+	var d Deque[int]
+	d.SetSpareLimit(4)
+	n := 3 << chunkShift[int]()
+	for i := range n {
+		d.PushBack(i)
+	}
+	for range n {
+		d.PopFront()
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for i := range n {
+			d.PushBack(i)
+		}
+		for range n {
+			d.PopFront()
+		}
+	})
+	require.Zero(t, allocs)
+
+	d.SetSpareLimit(0)
+	require.NotZero(t, testing.AllocsPerRun(10, func() {
+		d.PushBack(1)
+		d.PopBack()
+	}))
+
+	var pool ChunkPool[int]
+	d.SetChunkPool(&pool)
+
+	// sync.Pool may drop chunks, e.g.
+	// in race mode, so try a few times
+	taken, returned := false, false
+	for range 10 {
+		c := newChunk[int]()
+		pool.put(c)
+
+		d.PushBack(1)
+		used := d.chunks[d.head>>chunkShift[int]()]
+		taken = taken || used == c
+
+		d.PopBack()
+		returned = returned || pool.get() == used
+	}
+	require.True(t, taken, "chunk is not taken from pool")
+	require.True(t, returned, "chunk is not returned to pool")
+}
+
+func TestDeque_Shrink(t *testing.T) {
+	var d Deque[int]
+	for i := range 1 << 20 {
+		d.PushBack(i)
+	}
+	peak := len(d.chunks)
+
+	for range 1<<20 - 10 {
+		d.PopFront()
+	}
+	require.Less(t, len(d.chunks), peak/8)
+
+	d.Shrink()
+	require.Equal(t, 1, len(d.chunks))
+	require.Empty(t, d.spare)
+	require.Equal(t, []int{1<<20 - 10, 1<<20 - 9}, d.ToSlice()[:2])
+
+	d.Clear()
+	d.Shrink()
+	require.Nil(t, d.chunks)
+	d.PushFront(1)
+	require.Equal(t, 1, d.PopBack())
+}
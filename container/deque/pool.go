@@ -0,0 +1,21 @@
+package deque
+
+import "sync"
+
+// ChunkPool shares released chunks between deques of the same
+// element type, e.g. between many short-lived deques. It is
+// safe for concurrent use. Zero value is ready to use.
+type ChunkPool[T any] struct {
+	pool sync.Pool
+}
+
+func (p *ChunkPool[T]) get() *chunk[T] {
+	if c, ok := p.pool.Get().(*chunk[T]); ok {
+		return c
+	}
+	return newChunk[T]()
+}
+
+func (p *ChunkPool[T]) put(c *chunk[T]) {
+	p.pool.Put(c)
+}
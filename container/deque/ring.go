@@ -0,0 +1,177 @@
+package deque
+
+import "iter"
+
+// Policy defines what happens on push to the full Ring.
+type Policy uint8
+
+const (
+	// Reject makes push to the full ring fail.
+	Reject Policy = iota
+
+	// Overwrite makes push to the full ring drop the element
+	// at the opposite end, i.e. PushBack drops the front one,
+	// so the ring keeps the latest elements.
+	Overwrite
+)
+
+func NewRing[T any](capacity int, policy Policy) *Ring[T] {
+	if capacity <= 0 {
+		panic("ring capacity must be positive")
+	}
+
+	return &Ring[T]{
+		data:   make([]T, capacity),
+		policy: policy,
+	}
+}
+
+// Ring is deque of fixed capacity over the circular buffer.
+// It never allocates after creation, so it suits buffers of
+// recent events.
+type Ring[T any] struct {
+	data   []T
+	head   int
+	size   int
+	policy Policy
+}
+
+func (r *Ring[T]) Cap() int {
+	return len(r.data)
+}
+
+func (r *Ring[T]) Len() int {
+	return r.size
+}
+
+func (r *Ring[T]) IsEmpty() bool {
+	return r.size == 0
+}
+
+func (r *Ring[T]) IsFull() bool {
+	return r.size == len(r.data)
+}
+
+func (r *Ring[T]) Clear() {
+	clear(r.data)
+	r.head = 0
+	r.size = 0
+}
+
+// PushBack appends x. It returns false, if the ring is
+// full and its policy is Reject.
+func (r *Ring[T]) PushBack(x T) bool {
+	if r.IsFull() {
+		if r.policy == Reject {
+			return false
+		}
+		r.PopFront()
+	}
+
+	r.data[r.idx(r.size)] = x
+	r.size++
+	return true
+}
+
+// PushFront prepends x. It returns false, if the ring is
+// full and its policy is Reject.
+func (r *Ring[T]) PushFront(x T) bool {
+	if r.IsFull() {
+		if r.policy == Reject {
+			return false
+		}
+		r.PopBack()
+	}
+
+	r.head = r.idx(len(r.data) - 1)
+	r.data[r.head] = x
+	r.size++
+	return true
+}
+
+func (r *Ring[T]) PopFront() T {
+	x, ok := r.TryPopFront()
+	if !ok {
+		panic("pop of empty ring")
+	}
+	return x
+}
+
+func (r *Ring[T]) PopBack() T {
+	x, ok := r.TryPopBack()
+	if !ok {
+		panic("pop of empty ring")
+	}
+	return x
+}
+
+func (r *Ring[T]) TryPopFront() (T, bool) {
+	if r.size == 0 {
+		return *new(T), false
+	}
+
+	pop := r.data[r.head]
+	r.data[r.head] = *new(T)
+	r.head = r.idx(1)
+	r.size--
+	return pop, true
+}
+
+func (r *Ring[T]) TryPopBack() (T, bool) {
+	if r.size == 0 {
+		return *new(T), false
+	}
+
+	r.size--
+	i := r.idx(r.size)
+	pop := r.data[i]
+	r.data[i] = *new(T)
+	return pop, true
+}
+
+// Front returns the first element. It panics, if ring is empty.
+func (r *Ring[T]) Front() T {
+	return r.At(0)
+}
+
+// Back returns the last element. It panics, if ring is empty.
+func (r *Ring[T]) Back() T {
+	return r.At(r.size - 1)
+}
+
+// At returns i-th element from the front.
+func (r *Ring[T]) At(i int) T {
+	if i < 0 || i >= r.size {
+		panic("ring index out of range")
+	}
+	return r.data[r.idx(i)]
+}
+
+// All iterates over elements with their indices from the front.
+func (r *Ring[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := range r.size {
+			if !yield(i, r.data[r.idx(i)]) {
+				return
+			}
+		}
+	}
+}
+
+// ToSlice returns a copy of elements in order from the front.
+func (r *Ring[T]) ToSlice() []T {
+	s := make([]T, 0, r.size)
+	for _, x := range r.All() {
+		s = append(s, x)
+	}
+	return s
+}
+
+// idx returns index in data of i-th element from the front.
+func (r *Ring[T]) idx(i int) int {
+	i += r.head
+	if i >= len(r.data) {
+		i -= len(r.data)
+	}
+	return i
+}
@@ -0,0 +1,44 @@
+package deque
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRing_Overwrite(t *testing.T) {
+	r := NewRing[int](3, Overwrite)
+	for i := range 5 {
+		require.True(t, r.PushBack(i))
+	}
+	require.True(t, r.IsFull())
+	require.Equal(t, []int{2, 3, 4}, r.ToSlice())
+
+	require.True(t, r.PushFront(1))
+	require.Equal(t, []int{1, 2, 3}, r.ToSlice())
+	require.Equal(t, 1, r.Front())
+	require.Equal(t, 3, r.Back())
+	require.Equal(t, 2, r.At(1))
+}
+
+func TestRing_Reject(t *testing.T) {
+	r := NewRing[int](3, Reject)
+	for i := range 3 {
+		require.True(t, r.PushBack(i))
+	}
+	require.False(t, r.PushBack(3))
+	require.False(t, r.PushFront(-1))
+	require.Equal(t, []int{0, 1, 2}, r.ToSlice())
+
+	require.Equal(t, 0, r.PopFront())
+	require.Equal(t, 2, r.PopBack())
+	require.True(t, r.PushFront(-1))
+	require.Equal(t, []int{-1, 1}, r.ToSlice())
+
+	r.Clear()
+	_, ok := r.TryPopFront()
+	require.False(t, ok)
+	_, ok = r.TryPopBack()
+	require.False(t, ok)
+	require.Panics(t, func() { r.PopFront() })
+}
@@ -0,0 +1,494 @@
+// UNSTABLE
+package hashmap
+
+import (
+	"runtime"
+
+	"github.com/nikmy/algo/syncx"
+	"github.com/nikmy/algo/syncx/atomx"
+)
+
+const (
+	concurrentInitBuckets = 16
+	maxStripes            = 1024
+)
+
+func NewConcurrentCuckoo[K comparable, V any]() *ConcurrentCuckoo[K, V] {
+	m := NewConcurrentCuckooFunc[K, V](defaultHash[K](), defaultEqual[K])
+	m.reseed = defaultHash[K]
+	return m
+}
+
+// NewConcurrentCuckooFunc returns ConcurrentCuckoo with custom hash and equality.
+// Insert, Set and LoadOrStore panic, if more keys than fit into
+// two buckets have equal hashes, because no seed can separate them.
+func NewConcurrentCuckooFunc[K any, V any](hash Hash[K], equal Equal[K]) *ConcurrentCuckoo[K, V] {
+	m := &ConcurrentCuckoo[K, V]{equal: equal, reseed: reseeder(hash)}
+	m.table.Store(newConcurrentTable[K, V](concurrentInitBuckets, hash))
+	return m
+}
+
+// ConcurrentCuckoo is bucketized cuckoo hashmap, which is safe
+// for concurrent use, in the style of MemC3 and libcuckoo.
+//
+// Buckets are covered by lock stripes with version counters.
+// Readers never lock: they read versions of both candidate
+// buckets, look the key up and retry, if any version has been
+// changed, which means that entry might be moved between the
+// buckets. Writers lock stripes of the buckets they modify and
+// make odd version while modification is in progress. Cuckoo
+// path is searched without locks and every displacement along
+// the path is validated under locks of its two buckets.
+//
+// Resize locks all stripes of the old table, which is never
+// modified after that, so readers keep working during resize
+// and retry only after the new table is published.
+type ConcurrentCuckoo[K any, V any] struct {
+	equal  Equal[K]
+	reseed func() Hash[K]
+	table  atomx.Pointer[concurrentTable[K, V]]
+	count  atomx.Int64
+}
+
+// Len returns number of entries.
+func (m *ConcurrentCuckoo[K, V]) Len() int {
+	return int(m.count.Load())
+}
+
+func (m *ConcurrentCuckoo[K, V]) Lookup(key K) (V, bool) {
+	for {
+		t := m.table.Load()
+		b1, b2 := candidateBuckets(t.hash(key), t.mask)
+		s1, s2 := t.stripe(b1), t.stripe(b2)
+
+		v1, v2 := s1.version.Load(), s2.version.Load()
+		if v1&1 == 1 || v2&1 == 1 {
+			runtime.Gosched()
+			continue
+		}
+
+		e := t.buckets[b1].find(key, m.equal)
+		if e == nil {
+			e = t.buckets[b2].find(key, m.equal)
+		}
+
+		if s1.version.Load() != v1 || s2.version.Load() != v2 || m.table.Load() != t {
+			continue
+		}
+
+		if e == nil {
+			return *new(V), false
+		}
+		return e.v, true
+	}
+}
+
+// Insert inserts the entry, if there is no such key.
+func (m *ConcurrentCuckoo[K, V]) Insert(key K, value V) {
+	m.put(key, value, false)
+}
+
+// Set inserts the entry or overwrites value of the existing key.
+func (m *ConcurrentCuckoo[K, V]) Set(key K, value V) {
+	m.put(key, value, true)
+}
+
+// LoadOrStore returns the existing value for the key, if present.
+// Otherwise, it inserts and returns the given value.
+func (m *ConcurrentCuckoo[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if old := m.put(key, value, false); old != nil {
+		return old.v, true
+	}
+	return value, false
+}
+
+func (m *ConcurrentCuckoo[K, V]) Remove(key K) bool {
+	for {
+		t := m.table.Load()
+		b1, b2 := candidateBuckets(t.hash(key), t.mask)
+
+		t.lock(b1, b2)
+		if m.table.Load() != t {
+			t.unlock(b1, b2)
+			continue
+		}
+
+		removed := t.remove(b1, key, m.equal) || t.remove(b2, key, m.equal)
+		t.unlock(b1, b2)
+
+		if removed {
+			m.count.Add(-1)
+		}
+		return removed
+	}
+}
+
+func (m *ConcurrentCuckoo[K, V]) put(key K, value V, overwrite bool) *cuckooEntry[K, V] {
+	e := &cuckooEntry[K, V]{k: key, v: value}
+
+	for attempt := 0; ; {
+		t := m.table.Load()
+		b1, b2 := candidateBuckets(t.hash(key), t.mask)
+
+		t.lock(b1, b2)
+		if m.table.Load() != t {
+			t.unlock(b1, b2)
+			continue
+		}
+
+		old, done := t.put(b1, b2, e, overwrite, m.equal)
+		t.unlock(b1, b2)
+
+		if done {
+			if old == nil {
+				m.count.Add(1)
+			}
+			return old
+		}
+
+		if !m.displace(t, b1, b2) {
+			m.grow(t, attempt)
+			attempt++
+		}
+	}
+}
+
+// displace searches for cuckoo path without locks and executes
+// it step by step. It returns false, if there is no path, so
+// the table must grow. Path may become invalid because of
+// concurrent writers, then caller just tries again.
+func (m *ConcurrentCuckoo[K, V]) displace(t *concurrentTable[K, V], b1, b2 uint64) bool {
+	path, found := t.findPath(b1, b2, m.alternative(t))
+	if !found {
+		return false
+	}
+
+	for _, step := range path {
+		t.lock(step.from, step.to)
+		if m.table.Load() != t {
+			t.unlock(step.from, step.to)
+			return true
+		}
+
+		moved := t.move(step)
+		t.unlock(step.from, step.to)
+
+		if !moved {
+			return true
+		}
+	}
+
+	return true
+}
+
+// grow locks all stripes of t and replaces it with a table twice
+// bigger. Readers may use t meanwhile, because it is not changed.
+// Like Cuckoo, sparse table is rebuilt with new seed instead,
+// until attempts are exhausted.
+func (m *ConcurrentCuckoo[K, V]) grow(t *concurrentTable[K, V], attempt int) {
+	t.lockAll()
+	defer t.unlockAll()
+
+	if m.table.Load() != t {
+		return
+	}
+
+	n := t.len()
+	size, hash := len(t.buckets), m.reseed()
+	if 2*n >= size*bucketSlots || attempt >= maxRehashAttempts {
+		size, hash = 2*size, t.hash
+	}
+
+	for ; ; size *= 2 {
+		// table of 2n buckets fits any well spread keys
+		if size > len(t.buckets) && size > max(concurrentInitBuckets, 2*n+2) {
+			panic(errCollisions)
+		}
+
+		for range maxRehashAttempts {
+			grown := newConcurrentTable[K, V](size, hash)
+			if grown.fill(t, m) {
+				m.table.Store(grown)
+				return
+			}
+			hash = m.reseed()
+		}
+	}
+}
+
+func (m *ConcurrentCuckoo[K, V]) alternative(t *concurrentTable[K, V]) func(K, uint64) uint64 {
+	return func(key K, b uint64) uint64 {
+		b1, b2 := candidateBuckets(t.hash(key), t.mask)
+		if b1 == b {
+			return b2
+		}
+		return b1
+	}
+}
+
+func newConcurrentTable[K any, V any](size int, hash Hash[K]) *concurrentTable[K, V] {
+	stripes := min(size, maxStripes)
+	return &concurrentTable[K, V]{
+		hash:    hash,
+		buckets: make([]concurrentBucket[K, V], size),
+		stripes: make([]stripe, stripes),
+		mask:    uint64(size - 1),
+		smask:   uint64(stripes - 1),
+	}
+}
+
+// concurrentTable has its own hash, because
+// it may be reseeded, when the table grows.
+type concurrentTable[K any, V any] struct {
+	hash    Hash[K]
+	buckets []concurrentBucket[K, V]
+	stripes []stripe
+	mask    uint64
+	smask   uint64
+}
+
+// stripe protects a set of buckets. Its version
+// is odd, while any of them is being modified.
+type stripe struct {
+	lock    syncx.Mutex
+	version atomx.Uint64
+	_       [48]byte
+}
+
+func (t *concurrentTable[K, V]) stripe(b uint64) *stripe {
+	return &t.stripes[b&t.smask]
+}
+
+// lock locks stripes of two buckets in ascending order.
+func (t *concurrentTable[K, V]) lock(b1, b2 uint64) {
+	i, j := b1&t.smask, b2&t.smask
+	if i > j {
+		i, j = j, i
+	}
+
+	t.stripes[i].lock.Lock()
+	if i != j {
+		t.stripes[j].lock.Lock()
+	}
+}
+
+func (t *concurrentTable[K, V]) unlock(b1, b2 uint64) {
+	i, j := b1&t.smask, b2&t.smask
+	t.stripes[i].lock.Unlock()
+	if i != j {
+		t.stripes[j].lock.Unlock()
+	}
+}
+
+func (t *concurrentTable[K, V]) lockAll() {
+	for i := range t.stripes {
+		t.stripes[i].lock.Lock()
+	}
+}
+
+func (t *concurrentTable[K, V]) unlockAll() {
+	for i := range t.stripes {
+		t.stripes[i].lock.Unlock()
+	}
+}
+
+// bump increments versions of the stripes of the buckets.
+// Modification of the buckets must be surrounded by two
+// bumps, so readers can detect it. Stripes must be locked.
+func (t *concurrentTable[K, V]) bump(b1, b2 uint64) {
+	s1, s2 := t.stripe(b1), t.stripe(b2)
+	s1.version.Add(1)
+	if s1 != s2 {
+		s2.version.Add(1)
+	}
+}
+
+// put updates existing entry or inserts new one into a free slot.
+// It returns false, if both buckets are full. Stripes must be locked.
+func (t *concurrentTable[K, V]) put(b1, b2 uint64, e *cuckooEntry[K, V], overwrite bool, equal Equal[K]) (*cuckooEntry[K, V], bool) {
+	for _, b := range [2]uint64{b1, b2} {
+		slot := t.buckets[b].index(e.k, equal)
+		if slot < 0 {
+			continue
+		}
+
+		old := t.buckets[b].slots[slot].Load()
+		if overwrite {
+			t.bump(b, b)
+			t.buckets[b].slots[slot].Store(e)
+			t.bump(b, b)
+		}
+		return old, true
+	}
+
+	for _, b := range [2]uint64{b1, b2} {
+		if slot := t.buckets[b].free(); slot >= 0 {
+			t.bump(b, b)
+			t.buckets[b].slots[slot].Store(e)
+			t.bump(b, b)
+			return nil, true
+		}
+	}
+
+	return nil, false
+}
+
+func (t *concurrentTable[K, V]) remove(b uint64, key K, equal Equal[K]) bool {
+	slot := t.buckets[b].index(key, equal)
+	if slot < 0 {
+		return false
+	}
+
+	t.bump(b, b)
+	t.buckets[b].slots[slot].Store(nil)
+	t.bump(b, b)
+	return true
+}
+
+type displacement[K any, V any] struct {
+	from, to uint64
+	slot     int
+	entry    *cuckooEntry[K, V]
+}
+
+// move validates and executes one step of cuckoo path.
+// Stripes of both buckets must be locked.
+func (t *concurrentTable[K, V]) move(step displacement[K, V]) bool {
+	from, to := &t.buckets[step.from], &t.buckets[step.to]
+	if from.slots[step.slot].Load() != step.entry {
+		return false
+	}
+
+	free := to.free()
+	if free < 0 {
+		return false
+	}
+
+	t.bump(step.from, step.to)
+	to.slots[free].Store(step.entry)
+	from.slots[step.slot].Store(nil)
+	t.bump(step.from, step.to)
+
+	return true
+}
+
+// findPath searches for the shortest cuckoo path with BFS
+// without locks. Displacements are returned in order of
+// execution, so the first one moves entry to a free slot.
+func (t *concurrentTable[K, V]) findPath(b1, b2 uint64, alternative func(K, uint64) uint64) ([]displacement[K, V], bool) {
+	type node struct {
+		bucket uint64
+		parent int
+		step   displacement[K, V]
+		depth  int
+	}
+
+	queue := []node{{bucket: b1, parent: -1}, {bucket: b2, parent: -1}}
+	for i := 0; i < len(queue); i++ {
+		n := queue[i]
+
+		if t.buckets[n.bucket].free() >= 0 {
+			var path []displacement[K, V]
+			for ; n.parent >= 0; n = queue[n.parent] {
+				path = append(path, n.step)
+			}
+			return path, true
+		}
+
+		if n.depth == maxEvictionPath {
+			continue
+		}
+
+		for slot := range bucketSlots {
+			e := t.buckets[n.bucket].slots[slot].Load()
+			if e == nil {
+				continue
+			}
+
+			to := alternative(e.k, n.bucket)
+			queue = append(queue, node{
+				bucket: to,
+				parent: i,
+				step:   displacement[K, V]{from: n.bucket, to: to, slot: slot, entry: e},
+				depth:  n.depth + 1,
+			})
+		}
+	}
+
+	return nil, false
+}
+
+// len returns number of entries. Stripes must be locked.
+func (t *concurrentTable[K, V]) len() int {
+	n := 0
+	for i := range t.buckets {
+		for slot := range bucketSlots {
+			if t.buckets[i].slots[slot].Load() != nil {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// fill inserts all entries of old into unpublished table t.
+func (t *concurrentTable[K, V]) fill(old *concurrentTable[K, V], m *ConcurrentCuckoo[K, V]) bool {
+	for i := range old.buckets {
+		for slot := range bucketSlots {
+			e := old.buckets[i].slots[slot].Load()
+			if e == nil {
+				continue
+			}
+
+			b1, b2 := candidateBuckets(t.hash(e.k), t.mask)
+			for {
+				if _, done := t.put(b1, b2, e, false, m.equal); done {
+					break
+				}
+
+				path, found := t.findPath(b1, b2, m.alternative(t))
+				if !found {
+					return false
+				}
+				for _, step := range path {
+					if !t.move(step) {
+						return false
+					}
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+type concurrentBucket[K any, V any] struct {
+	slots [bucketSlots]atomx.Pointer[cuckooEntry[K, V]]
+}
+
+func (b *concurrentBucket[K, V]) find(key K, equal Equal[K]) *cuckooEntry[K, V] {
+	for i := range bucketSlots {
+		if e := b.slots[i].Load(); e != nil && equal(e.k, key) {
+			return e
+		}
+	}
+	return nil
+}
+
+func (b *concurrentBucket[K, V]) index(key K, equal Equal[K]) int {
+	for i := range bucketSlots {
+		if e := b.slots[i].Load(); e != nil && equal(e.k, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *concurrentBucket[K, V]) free() int {
+	for i := range bucketSlots {
+		if b.slots[i].Load() == nil {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,153 @@
+package hashmap
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikmy/algo/testx/faulty"
+	"github.com/nikmy/algo/testx/synctest"
+)
+
+func TestConcurrentCuckoo_Sequential(t *testing.T) {
+	m := NewConcurrentCuckoo[int, int]()
+
+	const n = 50_000
+	for i := 0; i < n; i++ {
+		m.Insert(i, i)
+	}
+	require.Equal(t, n, m.Len())
+
+	for i := 0; i < n; i += 2 {
+		m.Set(i, -i)
+	}
+	for i := 0; i < n; i += 3 {
+		require.True(t, m.Remove(i))
+	}
+	require.False(t, m.Remove(0))
+
+	for i := 0; i < n; i++ {
+		v, ok := m.Lookup(i)
+		require.Equal(t, i%3 != 0, ok)
+		if ok && i%2 == 0 {
+			require.Equal(t, -i, v)
+		} else if ok {
+			require.Equal(t, i, v)
+		}
+	}
+
+	actual, loaded := m.LoadOrStore(1, 100)
+	require.True(t, loaded)
+	require.Equal(t, 1, actual)
+}
+
+func TestConcurrentCuckoo_CollidingHash(t *testing.T) {
+	m := NewConcurrentCuckooFunc[int, int](func(x int) uint64 { return uint64(x) }, defaultEqual[int])
+	for i := range 10_000 {
+		m.Set(i<<20, i)
+	}
+	for i := range 10_000 {
+		v, ok := m.Lookup(i << 20)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+
+	m = NewConcurrentCuckooFunc[int, int](func(int) uint64 { return 42 }, defaultEqual[int])
+	require.PanicsWithValue(t, errCollisions, func() {
+		for i := range 100 {
+			m.Set(i, i)
+		}
+	})
+
+	// stripes are unlocked after panic
+	_, ok := m.Lookup(0)
+	require.True(t, ok)
+	require.True(t, m.Remove(0))
+}
+
+func TestConcurrentCuckoo_Safety(t *testing.T) {
+	m := NewConcurrentCuckoo[int, int]()
+
+	var i, j, l int
+
+	set := synctest.Operation{
+		Runner: func() { m.Set(i%2048, i); i++ },
+		Actors: 1,
+	}
+
+	remove := synctest.Operation{
+		Runner: func() { m.Remove(j % 2048); j += 3 },
+		Actors: 1,
+	}
+
+	lookup := synctest.Operation{
+		Runner: func() { m.Lookup(l % 2048); l += 7 },
+		Actors: 1,
+	}
+
+	c := faulty.NewController(t, 42)
+	c.SetFaultProbability(0.2)
+
+	synctest.Stress(t, c, 100_000, set, remove, lookup)
+}
+
+func TestConcurrentCuckoo_ReadersDuringDisplacement(t *testing.T) {
+	const (
+		stable  = 1_000
+		writers = 4
+		readers = 4
+	)
+
+	m := NewConcurrentCuckoo[int, int]()
+	for k := 0; k < stable; k++ {
+		m.Insert(k, k)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		done = make(chan struct{})
+	)
+
+	// stable keys are moved by displacements and resizes,
+	// but readers must always find them
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for k := 0; k < stable; k++ {
+					v, ok := m.Lookup(k)
+					require.True(t, ok, "key %d is lost", k)
+					require.Equal(t, k, v)
+				}
+			}
+		}()
+	}
+
+	var ww sync.WaitGroup
+	for w := range writers {
+		ww.Add(1)
+		go func() {
+			defer ww.Done()
+			for i := range 50_000 {
+				k := stable + w*50_000 + i
+				m.Insert(k, k)
+				if i%4 == 0 {
+					m.Remove(k)
+				}
+			}
+		}()
+	}
+
+	ww.Wait()
+	close(done)
+	wg.Wait()
+
+	require.Equal(t, stable+writers*50_000*3/4, m.Len())
+}
@@ -2,168 +2,504 @@
 package hashmap
 
 import (
-	"math"
-	"math/rand/v2"
-
-	"github.com/dolthub/maphash"
+	"iter"
+	"math/bits"
 )
 
-type Hash[T any] func(T) uintptr // TODO: universal hash for all types
-
 const (
-	notFound uint64 = 0xFFFFFFFFFFFFFFF
-	baseXor  uint64 = 0x101010101
+	// bucketSlots is the number of entries in one bucket.
+	bucketSlots = 4
+
+	// stashSize is the number of entries, that did not fit
+	// into their buckets. Lookups scan the stash, so it must
+	// be small to keep worst-case lookup O(1).
+	stashSize = 4
+
+	// maxEvictionPath is the maximal number of displacements
+	// made by a single insert.
+	maxEvictionPath = 4
+
+	// maxRehashAttempts is the number of seeds tried
+	// before table grows during rehash.
+	maxRehashAttempts = 4
+
+	// reserveLoadFactor is the load factor
+	// Reserve sizes the table for.
+	reserveLoadFactor = 0.9
+
+	emptyTag uint8 = 0
 )
 
 func NewCuckoo[K comparable, V any]() *Cuckoo[K, V] {
+	c := NewCuckooFunc[K, V](defaultHash[K](), defaultEqual[K])
+	c.reseed = defaultHash[K]
+	return c
+}
+
+// NewCuckooFunc returns Cuckoo with custom hash and equality.
+// Insert, Set and LoadOrStore panic, if more keys than fit into
+// two buckets and the stash have equal hashes, because no seed
+// can separate them.
+func NewCuckooFunc[K any, V any](hash Hash[K], equal Equal[K]) *Cuckoo[K, V] {
 	return &Cuckoo[K, V]{
-		mainHash: maphash.NewHasher[K](),
-		xorValue: rand.N[uint64](math.MaxUint64) & baseXor,
-		logB:     2,
-		buckets:  make([]cuckooBucket[K, V], 4),
+		hash:    hash,
+		equal:   equal,
+		reseed:  reseeder(hash),
+		logB:    1,
+		buckets: make([]cuckooBucket[K, V], 2),
+		stash:   make([]cuckooEntry[K, V], 0, stashSize),
 	}
 }
 
 // Cuckoo is hashmap optimized for reads. It has fair O(1)
 // asymptotic for lookups and removes, and amortized O*(1)
-// asymptotic for inserts. It is guaranteed that memory is
-// used for storing elements is O(4N).
-type Cuckoo[K comparable, V any] struct {
-	mainHash maphash.Hasher[K]
-	xorValue uint64
-	logB     uint8
-	buckets  []cuckooBucket[K, V]
-	busy     int
+// asymptotic for inserts.
+//
+// Every key may be stored in one of the two buckets of four
+// slots each, or in a small stash. Inserts find the shortest
+// chain of displacements with BFS, so table is filled up to
+// ~95% before it grows, and memory used for storing elements
+// is about O(1.1N).
+type Cuckoo[K any, V any] struct {
+	hash    Hash[K]
+	equal   Equal[K]
+	reseed  func() Hash[K]
+	logB    uint8
+	buckets []cuckooBucket[K, V]
+	stash   []cuckooEntry[K, V]
+	busy    int
 }
 
+// Len returns number of entries.
 func (c *Cuckoo[K, V]) Len() int {
-	return 1 << c.logB
+	return c.busy
+}
+
+// Cap returns number of slots in the table.
+func (c *Cuckoo[K, V]) Cap() int {
+	return len(c.buckets)*bucketSlots + stashSize
 }
 
 func (c *Cuckoo[K, V]) Lookup(key K) (V, bool) {
-	kv, _ := c.lookup(key)
-	return kv.v, kv.ok
+	b, slot, found := c.lookup(key)
+	if !found {
+		return *new(V), false
+	}
+
+	if b == stashBucket {
+		return c.stash[slot].v, true
+	}
+
+	return c.buckets[b].vals[slot], true
 }
 
 func (c *Cuckoo[K, V]) Remove(key K) bool {
-	_, b := c.lookup(key)
-	if b == notFound {
+	b, slot, found := c.lookup(key)
+	if !found {
 		return false
 	}
 
-	c.buckets[b] = cuckooBucket[K, V]{}
 	c.busy--
+
+	if b == stashBucket {
+		last := len(c.stash) - 1
+		c.stash[slot] = c.stash[last]
+		c.stash[last] = cuckooEntry[K, V]{}
+		c.stash = c.stash[:last]
+		return true
+	}
+
+	c.buckets[b].clear(slot)
+	c.unstash(b)
 	return true
 }
 
+// Insert inserts the entry, if there is no such key.
 func (c *Cuckoo[K, V]) Insert(key K, value V) {
-	for !c.tryInsert(key, value) {
-		c.evacuate()
+	if _, _, found := c.lookup(key); found {
+		return
 	}
-	c.busy++
+
+	c.insert(key, value)
 }
 
-func (c *Cuckoo[K, V]) lookup(key K) (cuckooBucket[K, V], uint64) {
-	b := c.hash(key)
+// Set inserts the entry or overwrites value of the existing key.
+func (c *Cuckoo[K, V]) Set(key K, value V) {
+	if v := c.valueRef(key); v != nil {
+		*v = value
+		return
+	}
+
+	c.insert(key, value)
+}
 
-	first := c.buckets[b]
-	if first.ok && first.k == key {
-		return first, b
+// LoadOrStore returns the existing value for the key, if present.
+// Otherwise, it inserts and returns the given value.
+func (c *Cuckoo[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if v := c.valueRef(key); v != nil {
+		return *v, true
 	}
 
-	b = c.flipHash(b)
-	second := c.buckets[b]
-	if second.ok && second.k == key {
-		return second, b
+	c.insert(key, value)
+	return value, false
+}
+
+// All iterates over all entries in unspecified order.
+// The map must not be modified during iteration.
+func (c *Cuckoo[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := range c.buckets {
+			b := &c.buckets[i]
+			for slot := range bucketSlots {
+				if b.tags[slot] != emptyTag && !yield(b.keys[slot], b.vals[slot]) {
+					return
+				}
+			}
+		}
+
+		for _, e := range c.stash {
+			if !yield(e.k, e.v) {
+				return
+			}
+		}
 	}
+}
 
-	return cuckooBucket[K, V]{}, notFound
+func (c *Cuckoo[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range c.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+func (c *Cuckoo[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range c.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
 }
 
-func (c *Cuckoo[K, V]) tryInsert(key K, value V) bool {
-	b := c.hash(key)
+// Clear removes all entries, but keeps allocated memory.
+func (c *Cuckoo[K, V]) Clear() {
+	clear(c.buckets)
+	clear(c.stash)
+	c.stash = c.stash[:0]
+	c.busy = 0
+}
 
-	first := c.buckets[b]
-	if !first.ok {
-		c.buckets[b] = cuckooBucket[K, V]{true, key, value}
-		return true
+// Clone returns a copy of the map, which shares nothing with it.
+func (c *Cuckoo[K, V]) Clone() *Cuckoo[K, V] {
+	clone := *c
+	clone.buckets = append([]cuckooBucket[K, V](nil), c.buckets...)
+	clone.stash = append(make([]cuckooEntry[K, V], 0, stashSize), c.stash...)
+	return &clone
+}
+
+// Reserve grows the table, so n entries
+// can be stored without further growth.
+func (c *Cuckoo[K, V]) Reserve(n int) {
+	need := int(float64(n)/(bucketSlots*reserveLoadFactor)) + 1
+	logB := uint8(bits.Len(uint(need - 1)))
+	if logB <= c.logB {
+		return
 	}
-	if first.k == key {
-		return true
+
+	entries := c.entries()
+	c.logB = logB
+	c.rebuild(entries)
+}
+
+func (c *Cuckoo[K, V]) insert(key K, value V) {
+	// evacuate may reseed hash
+	for attempt := 0; !c.tryInsert(c.hash(key), key, value); attempt++ {
+		c.evacuate(attempt)
+	}
+	c.busy++
+}
+
+func (c *Cuckoo[K, V]) valueRef(key K) *V {
+	b, slot, found := c.lookup(key)
+	switch {
+	case !found:
+		return nil
+	case b == stashBucket:
+		return &c.stash[slot].v
+	default:
+		return &c.buckets[b].vals[slot]
 	}
+}
 
-	b = c.flipHash(b)
-	second := c.buckets[b]
-	if !second.ok {
-		c.buckets[b] = cuckooBucket[K, V]{true, key, value}
-		return true
+// stashBucket is returned by lookup for entries in the stash.
+const stashBucket = ^uint64(0)
+
+func (c *Cuckoo[K, V]) lookup(key K) (uint64, int, bool) {
+	h := c.hash(key)
+	tag := hashTag(h)
+
+	b1, b2 := c.candidates(h)
+	if slot := c.buckets[b1].find(tag, key, c.equal); slot >= 0 {
+		return b1, slot, true
 	}
-	if second.k == key {
+	if slot := c.buckets[b2].find(tag, key, c.equal); slot >= 0 {
+		return b2, slot, true
+	}
+
+	for i := range c.stash {
+		if c.equal(c.stash[i].k, key) {
+			return stashBucket, i, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+func (c *Cuckoo[K, V]) tryInsert(h uint64, key K, value V) bool {
+	tag := hashTag(h)
+	b1, b2 := c.candidates(h)
+
+	if c.buckets[b1].put(tag, key, value) || c.buckets[b2].put(tag, key, value) {
 		return true
 	}
 
-	if c.free(b) {
-		c.buckets[b] = cuckooBucket[K, V]{true, key, value}
+	if b, ok := c.displace(b1, b2); ok {
+		return c.buckets[b].put(tag, key, value)
+	}
+
+	if len(c.stash) < stashSize {
+		c.stash = append(c.stash, cuckooEntry[K, V]{k: key, v: value})
 		return true
 	}
 
 	return false
 }
 
-func (c *Cuckoo[K, V]) flipHash(h uint64) uint64 {
-	return h ^ (c.xorValue & uint64(c.Len()-1))
+// candidates returns two buckets, where key with hash h may be stored.
+func (c *Cuckoo[K, V]) candidates(h uint64) (uint64, uint64) {
+	return candidateBuckets(h, uint64(len(c.buckets)-1))
+}
+
+func candidateBuckets(h uint64, mask uint64) (uint64, uint64) {
+	b1 := h & mask
+	b2 := (h >> 32) & mask
+	if b1 == b2 {
+		b2 = b1 ^ 1
+	}
+	return b1, b2
 }
 
-func (c *Cuckoo[K, V]) hash(key K) uint64 {
+// alternative returns the other bucket for the key stored in bucket b.
+func (c *Cuckoo[K, V]) alternative(key K, b uint64) uint64 {
+	b1, b2 := c.candidates(c.hash(key))
+	if b1 == b {
+		return b2
+	}
+	return b1
+}
 
-	return c.mainHash.Hash(key) & uint64(c.Len()-1)
+type evictionNode struct {
+	bucket uint64
+	parent int
+	slot   int
+	depth  int
 }
 
-func (c *Cuckoo[K, V]) free(b uint64) bool {
-	swapPath := make([]uint64, 0, 64)
-	for len(swapPath) < c.Len() && c.buckets[b].ok {
-		swapPath = append(swapPath, b)
-		b = c.flipHash(b)
+// displace searches for the shortest chain of displacements
+// with BFS starting from b1 and b2, which ends in a bucket with
+// a free slot. If found, it moves entries along the chain and
+// returns one of b1 and b2, which has a free slot now.
+func (c *Cuckoo[K, V]) displace(b1, b2 uint64) (uint64, bool) {
+	queue := make([]evictionNode, 0, 64)
+	queue = append(queue,
+		evictionNode{bucket: b1, parent: -1},
+		evictionNode{bucket: b2, parent: -1},
+	)
+
+	for i := 0; i < len(queue); i++ {
+		node := queue[i]
+		if node.depth == maxEvictionPath {
+			continue
+		}
+
+		bucket := &c.buckets[node.bucket]
+		for slot := range bucketSlots {
+			child := evictionNode{
+				bucket: c.alternative(bucket.keys[slot], node.bucket),
+				parent: i,
+				slot:   slot,
+				depth:  node.depth + 1,
+			}
+
+			if c.buckets[child.bucket].free() >= 0 {
+				queue = append(queue, child)
+				return c.moveAlong(queue, len(queue)-1)
+			}
+
+			queue = append(queue, child)
+		}
 	}
 
-	if len(swapPath) >= c.Len() {
-		return false
+	return 0, false
+}
+
+// moveAlong moves entries from the leaf of the eviction
+// chain to the root, so the root bucket gets a free slot.
+// Chain may visit the same bucket twice, so every move is
+// checked and the process is interrupted, if it is invalid.
+// Interrupted chain still leaves the table consistent.
+func (c *Cuckoo[K, V]) moveAlong(queue []evictionNode, leaf int) (uint64, bool) {
+	for node := queue[leaf]; node.parent >= 0; node = queue[node.parent] {
+		from := &c.buckets[queue[node.parent].bucket]
+		to := &c.buckets[node.bucket]
+
+		if from.tags[node.slot] == emptyTag {
+			return 0, false
+		}
+
+		key := from.keys[node.slot]
+		if c.alternative(key, queue[node.parent].bucket) != node.bucket {
+			return 0, false
+		}
+
+		if !to.put(from.tags[node.slot], key, from.vals[node.slot]) {
+			return 0, false
+		}
+
+		from.clear(node.slot)
 	}
 
-	for len(swapPath) > 0 {
-		s := len(swapPath) - 1
-		c.buckets[b], c.buckets[s] = c.buckets[s], c.buckets[b]
-		b = swapPath[s]
+	root := queue[leaf]
+	for root.parent >= 0 {
+		root = queue[root.parent]
 	}
 
-	return true
+	return root.bucket, c.buckets[root.bucket].free() >= 0
 }
 
-func (c *Cuckoo[K, V]) overflow() bool {
-	return c.busy > c.Len()/4
+// unstash moves an entry from stash to bucket b,
+// if b is one of its buckets and has a free slot.
+func (c *Cuckoo[K, V]) unstash(b uint64) {
+	for i, e := range c.stash {
+		h := c.hash(e.k)
+		if b1, b2 := c.candidates(h); b1 != b && b2 != b {
+			continue
+		}
+
+		c.buckets[b].put(hashTag(h), e.k, e.v)
+
+		last := len(c.stash) - 1
+		c.stash[i] = c.stash[last]
+		c.stash[last] = cuckooEntry[K, V]{}
+		c.stash = c.stash[:last]
+		return
+	}
 }
 
-func (c *Cuckoo[K, V]) evacuate() {
-	oldBuckets := c.buckets
-	if c.overflow() {
-		c.logB++
+// evacuate rebuilds the table with new seed. The table grows,
+// unless it is sparse, so that insert has failed because of
+// unlucky hash, or attempts to reseed are exhausted.
+func (c *Cuckoo[K, V]) evacuate(attempt int) {
+	entries := c.entries()
+	if 2*len(entries) >= c.Cap() || attempt >= maxRehashAttempts {
+		c.grow(len(entries))
 	}
-	c.buckets = make([]cuckooBucket[K, V], c.Len())
+	c.hash = c.reseed()
+	c.rebuild(entries)
+}
 
-tryHash:
-	for _, kv := range oldBuckets {
-		if !kv.ok {
-			continue
+func (c *Cuckoo[K, V]) entries() []cuckooEntry[K, V] {
+	entries := make([]cuckooEntry[K, V], 0, c.busy)
+	for k, v := range c.All() {
+		entries = append(entries, cuckooEntry[K, V]{k, v})
+	}
+	return entries
+}
+
+func (c *Cuckoo[K, V]) rebuild(entries []cuckooEntry[K, V]) {
+	for attempt := 0; !c.rehash(entries); attempt++ {
+		if attempt == maxRehashAttempts {
+			c.grow(len(entries))
+			attempt = 0
+		}
+		c.hash = c.reseed()
+	}
+}
+
+// grow doubles the table of n entries. Table of 2n buckets
+// fits any well spread keys, so it panics, if the table is
+// already bigger, because keys must have colliding hashes.
+func (c *Cuckoo[K, V]) grow(n int) {
+	if 1<<c.logB > 2*n+2 {
+		panic(errCollisions)
+	}
+	c.logB++
+}
+
+func (c *Cuckoo[K, V]) rehash(entries []cuckooEntry[K, V]) bool {
+	c.buckets = make([]cuckooBucket[K, V], 1<<c.logB)
+	c.stash = c.stash[:0]
+	for _, e := range entries {
+		if !c.tryInsert(c.hash(e.k), e.k, e.v) {
+			return false
+		}
+	}
+	return true
+}
+
+// hashTag is a non-zero fingerprint of the key,
+// which is used to skip most key comparisons.
+func hashTag(h uint64) uint8 {
+	return uint8(h>>56) | 1
+}
+
+type cuckooEntry[K any, V any] struct {
+	k K
+	v V
+}
+
+type cuckooBucket[K any, V any] struct {
+	tags [bucketSlots]uint8
+	keys [bucketSlots]K
+	vals [bucketSlots]V
+}
+
+func (b *cuckooBucket[K, V]) find(tag uint8, key K, equal Equal[K]) int {
+	for i := range bucketSlots {
+		if b.tags[i] == tag && equal(b.keys[i], key) {
+			return i
 		}
-		if !c.tryInsert(kv.k, kv.v) {
-			c.mainHash = maphash.NewSeed[K](c.mainHash)
-			goto tryHash
+	}
+	return -1
+}
+
+func (b *cuckooBucket[K, V]) free() int {
+	for i := range bucketSlots {
+		if b.tags[i] == emptyTag {
+			return i
 		}
 	}
+	return -1
+}
+
+func (b *cuckooBucket[K, V]) put(tag uint8, key K, value V) bool {
+	i := b.free()
+	if i < 0 {
+		return false
+	}
+
+	b.tags[i] = tag
+	b.keys[i] = key
+	b.vals[i] = value
+	return true
 }
 
-type cuckooBucket[K comparable, V any] struct {
-	ok bool
-	k  K
-	v  V
+func (b *cuckooBucket[K, V]) clear(slot int) {
+	b.tags[slot] = emptyTag
+	b.keys[slot] = *new(K)
+	b.vals[slot] = *new(V)
 }
@@ -1,7 +1,10 @@
 package hashmap
 
 import (
+	"fmt"
+	"maps"
 	"math/rand"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -29,6 +32,111 @@ func TestCuckoo(t *testing.T) {
 		require.Equal(t, v, got)
 	}
 
-	require.Less(t, m.Len()/n, 8, "overhead over 100x")
+	require.Equal(t, len(et), m.Len())
+	require.Less(t, m.Cap()/n, 8, "overhead over 100x")
 
 }
+
+func TestCuckoo_LoadFactor(t *testing.T) {
+	m := NewCuckoo[int, int]()
+
+	const n = 100_000
+
+	maxLoad := 0.0
+	for i := 0; i < n; i++ {
+		capacity := m.Cap()
+		m.Insert(i, i)
+		if m.Cap() != capacity && capacity >= 1024 {
+			maxLoad = max(maxLoad, float64(i)/float64(capacity))
+		}
+	}
+
+	require.Greater(t, maxLoad, 0.9)
+
+	for i := 0; i < n; i++ {
+		v, ok := m.Lookup(i)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+
+	for i := 0; i < n; i += 2 {
+		require.True(t, m.Remove(i))
+	}
+
+	for i := 0; i < n; i++ {
+		_, ok := m.Lookup(i)
+		require.Equal(t, i%2 == 1, ok)
+	}
+}
+
+func TestCuckoo_MapAPI(t *testing.T) {
+	m := NewCuckoo[string, int]()
+
+	m.Insert("a", 1)
+	m.Insert("a", 2)
+	v, _ := m.Lookup("a")
+	require.Equal(t, 1, v, "insert must not overwrite")
+
+	m.Set("a", 3)
+	v, _ = m.Lookup("a")
+	require.Equal(t, 3, v)
+
+	actual, loaded := m.LoadOrStore("a", 4)
+	require.True(t, loaded)
+	require.Equal(t, 3, actual)
+
+	actual, loaded = m.LoadOrStore("b", 5)
+	require.False(t, loaded)
+	require.Equal(t, 5, actual)
+	require.Equal(t, 2, m.Len())
+
+	m.Reserve(1000)
+	require.GreaterOrEqual(t, m.Cap(), 1000)
+	capacity := m.Cap()
+
+	et := map[string]int{"a": 3, "b": 5}
+	for i := range 900 {
+		k := fmt.Sprint("key", i)
+		m.Set(k, i)
+		et[k] = i
+	}
+	require.Equal(t, capacity, m.Cap(), "reserved map has grown")
+	require.Equal(t, len(et), m.Len())
+
+	require.Equal(t, et, maps.Collect(m.All()))
+	require.ElementsMatch(t, slices.Collect(maps.Keys(et)), slices.Collect(m.Keys()))
+	require.ElementsMatch(t, slices.Collect(maps.Values(et)), slices.Collect(m.Values()))
+
+	clone := m.Clone()
+	m.Clear()
+	require.Equal(t, 0, m.Len())
+	require.Equal(t, capacity, m.Cap())
+	_, ok := m.Lookup("a")
+	require.False(t, ok)
+
+	require.Equal(t, et, maps.Collect(clone.All()))
+	clone.Set("a", 42)
+	_, ok = m.Lookup("a")
+	require.False(t, ok)
+}
+
+func TestCuckoo_CollidingHash(t *testing.T) {
+	// identity doesn't mix the bits, but
+	// seeded variants of it spread the keys
+	m := NewCuckooFunc[int, int](func(x int) uint64 { return uint64(x) }, defaultEqual[int])
+	for i := range 10_000 {
+		m.Set(i<<20, i)
+	}
+	for i := range 10_000 {
+		v, ok := m.Lookup(i << 20)
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+
+	m = NewCuckooFunc[int, int](func(int) uint64 { return 42 }, defaultEqual[int])
+	require.PanicsWithValue(t, errCollisions, func() {
+		for i := range 100 {
+			m.Set(i, i)
+		}
+	})
+}
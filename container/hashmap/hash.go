@@ -0,0 +1,166 @@
+package hashmap
+
+import (
+	"bytes"
+	"math/bits"
+	"math/rand/v2"
+
+	"github.com/dolthub/maphash"
+	"golang.org/x/exp/constraints"
+)
+
+// Hash is hash function for keys of type T. Maps take bucket
+// indices both from low and high bits of the hash, so all 64
+// bits must be well mixed.
+//
+// Custom Hash and Equal allow non-comparable keys or custom
+// equality, e.g. case-insensitive strings.
+type Hash[T any] func(T) uint64
+
+// Equal reports whether two keys are the same. It must be
+// consistent with Hash: equal keys must have equal hashes.
+type Equal[T any] func(a, b T) bool
+
+func defaultHash[K comparable]() Hash[K] {
+	return maphash.NewHasher[K]().Hash
+}
+
+func defaultEqual[K comparable](a, b K) bool {
+	return a == b
+}
+
+// reseeder returns generator of seeded variants of custom hash,
+// so tables can be rebuilt, if hash doesn't spread the keys.
+// It doesn't help keys with equal hashes.
+func reseeder[K any](hash Hash[K]) func() Hash[K] {
+	return func() Hash[K] {
+		seed := rand.Uint64()
+		return func(key K) uint64 {
+			return xxAvalanche(hash(key) ^ seed)
+		}
+	}
+}
+
+// errCollisions is panic message of tables,
+// which can't place keys even after reseeding.
+const errCollisions = "hashmap: too many keys with colliding hashes"
+
+// StringHash returns xxHash64 of strings with given seed.
+func StringHash[S ~string](seed uint64) Hash[S] {
+	return func(s S) uint64 {
+		return xxh64(s, seed)
+	}
+}
+
+// BytesHash returns xxHash64 of byte slices with given seed.
+// Use it with BytesEqual, because slices are not comparable.
+func BytesHash(seed uint64) Hash[[]byte] {
+	return func(b []byte) uint64 {
+		return xxh64(b, seed)
+	}
+}
+
+// BytesEqual compares byte slices by contents.
+func BytesEqual(a, b []byte) bool {
+	return bytes.Equal(a, b)
+}
+
+// IntHash returns Fibonacci hash of integers. Multiplication
+// by 2^64/phi mixes the bits into the high half of the product,
+// which is then folded into the low half.
+func IntHash[T constraints.Integer](seed uint64) Hash[T] {
+	return func(x T) uint64 {
+		h := (uint64(x) ^ seed) * fibonacciMultiplier
+		return h ^ (h >> 32)
+	}
+}
+
+const fibonacciMultiplier = 11400714819323198485
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+func xxh64[S ~string | ~[]byte](s S, seed uint64) uint64 {
+	n := len(s)
+
+	var h uint64
+	if n >= 32 {
+		v1 := seed + xxPrime1 + xxPrime2
+		v2 := seed + xxPrime2
+		v3 := seed
+		v4 := seed - xxPrime1
+		for ; len(s) >= 32; s = s[32:] {
+			v1 = xxRound(v1, readUint64(s[0:8]))
+			v2 = xxRound(v2, readUint64(s[8:16]))
+			v3 = xxRound(v3, readUint64(s[16:24]))
+			v4 = xxRound(v4, readUint64(s[24:32]))
+		}
+
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) +
+			bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound(h, v1)
+		h = xxMergeRound(h, v2)
+		h = xxMergeRound(h, v3)
+		h = xxMergeRound(h, v4)
+	} else {
+		h = seed + xxPrime5
+	}
+
+	h += uint64(n)
+
+	for ; len(s) >= 8; s = s[8:] {
+		h ^= xxRound(0, readUint64(s[:8]))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+
+	if len(s) >= 4 {
+		h ^= uint64(readUint32(s[:4])) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		s = s[4:]
+	}
+
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i]) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	return xxAvalanche(h)
+}
+
+// xxAvalanche mixes all bits of h, so that every
+// input bit affects every output bit.
+func xxAvalanche(h uint64) uint64 {
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
+
+func readUint64[S ~string | ~[]byte](b S) uint64 {
+	_ = b[7]
+	return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24 |
+		uint64(b[4])<<32 | uint64(b[5])<<40 | uint64(b[6])<<48 | uint64(b[7])<<56
+}
+
+func readUint32[S ~string | ~[]byte](b S) uint32 {
+	_ = b[3]
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
@@ -0,0 +1,84 @@
+package hashmap
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXXH64(t *testing.T) {
+	require.Equal(t, uint64(0xEF46DB3751D8E999), xxh64("", 0))
+	require.Equal(t, uint64(0x44BC2CF5AD770999), xxh64("abc", 0))
+
+	long := strings.Repeat("0123456789", 10)
+	require.Equal(t, xxh64(long, 7), xxh64([]byte(long), 7))
+	require.NotEqual(t, xxh64(long, 7), xxh64(long, 8))
+}
+
+func TestHash_CaseInsensitive(t *testing.T) {
+	hash := StringHash[string](42)
+	lower := func(s string) uint64 { return hash(strings.ToLower(s)) }
+
+	impls := map[string]interface {
+		Set(string, int)
+		Lookup(string) (int, bool)
+		Len() int
+	}{
+		"cuckoo":            NewCuckooFunc[string, int](lower, strings.EqualFold),
+		"robinhood":         NewRobinHoodFunc[string, int](lower, strings.EqualFold),
+		"concurrent_cuckoo": NewConcurrentCuckooFunc[string, int](lower, strings.EqualFold),
+	}
+
+	for name, m := range impls {
+		t.Run(name, func(t *testing.T) {
+			for i := range 1000 {
+				m.Set("Key"+strconv.Itoa(i), i)
+			}
+			m.Set("KEY0", -1)
+
+			require.Equal(t, 1000, m.Len())
+			for i := range 1000 {
+				v, ok := m.Lookup("kEy" + strconv.Itoa(i))
+				require.True(t, ok)
+				if i > 0 {
+					require.Equal(t, i, v)
+				} else {
+					require.Equal(t, -1, v)
+				}
+			}
+		})
+	}
+}
+
+func TestHash_Bytes(t *testing.T) {
+	m := NewCuckooFunc[[]byte, int](BytesHash(0), BytesEqual)
+	for i := range 1000 {
+		m.Set([]byte(strconv.Itoa(i)), i)
+	}
+
+	require.Equal(t, 1000, m.Len())
+	for i := range 1000 {
+		v, ok := m.Lookup([]byte(strconv.Itoa(i)))
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+	require.True(t, m.Remove([]byte("42")))
+	_, ok := m.Lookup([]byte("42"))
+	require.False(t, ok)
+}
+
+func TestHash_Int(t *testing.T) {
+	m := NewRobinHoodFunc[uint32, int](IntHash[uint32](0), func(a, b uint32) bool { return a == b })
+	for i := range uint32(10_000) {
+		m.Set(i<<16, int(i))
+	}
+
+	require.Equal(t, 10_000, m.Len())
+	for i := range uint32(10_000) {
+		v, ok := m.Lookup(i << 16)
+		require.True(t, ok)
+		require.Equal(t, int(i), v)
+	}
+}
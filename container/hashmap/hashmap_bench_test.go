@@ -0,0 +1,132 @@
+package hashmap
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+// benchMap is the common API of maps, that are compared
+// with each other and with builtin map.
+type benchMap[K comparable] interface {
+	Lookup(K) (int, bool)
+	Set(K, int)
+	Remove(K) bool
+	Reserve(int)
+	Cap() int
+}
+
+type builtinMap[K comparable] struct {
+	m map[K]int
+}
+
+func (b *builtinMap[K]) Lookup(k K) (int, bool) {
+	v, ok := b.m[k]
+	return v, ok
+}
+
+func (b *builtinMap[K]) Set(k K, v int) {
+	b.m[k] = v
+}
+
+func (b *builtinMap[K]) Remove(k K) bool {
+	_, ok := b.m[k]
+	delete(b.m, k)
+	return ok
+}
+
+func (b *builtinMap[K]) Reserve(n int) {
+	b.m = make(map[K]int, n)
+}
+
+// Cap returns zero, because builtin
+// map does not expose its capacity.
+func (b *builtinMap[K]) Cap() int {
+	return 0
+}
+
+type benchImpl[K comparable] struct {
+	name string
+	init func() benchMap[K]
+}
+
+func benchImpls[K comparable]() []benchImpl[K] {
+	return []benchImpl[K]{
+		{"builtin", func() benchMap[K] { return &builtinMap[K]{m: map[K]int{}} }},
+		{"Cuckoo", func() benchMap[K] { return NewCuckoo[K, int]() }},
+		{"RobinHood", func() benchMap[K] { return NewRobinHood[K, int]() }},
+	}
+}
+
+const benchTableSize = 1 << 16
+
+var benchLoadFactors = []float64{0.25, 0.5, 0.75, 0.9}
+
+func BenchmarkMaps(b *testing.B) {
+	ints := make([]int, 2*benchTableSize)
+	strs := make([]string, 2*benchTableSize)
+	for i := range ints {
+		ints[i] = i * 0x9E3779B1
+		strs[i] = "key:" + strconv.Itoa(ints[i])
+	}
+
+	b.Run("int", func(b *testing.B) { benchMaps(b, ints) })
+	b.Run("string", func(b *testing.B) { benchMaps(b, strs) })
+}
+
+// benchMaps fills tables with the number of entries, which
+// makes load factors of the same power-of-two table different.
+// Actual load factor depends on growth policy of a map, so it
+// is reported as a metric. The second half of keys is never
+// inserted, it is used for lookup misses.
+func benchMaps[K comparable](b *testing.B, keys []K) {
+	present, absent := keys[:len(keys)/2], keys[len(keys)/2:]
+
+	for _, lf := range benchLoadFactors {
+		for _, impl := range benchImpls[K]() {
+			fill := func() (benchMap[K], int) {
+				m := impl.init()
+				n := int(lf * benchTableSize)
+				for _, k := range present[:n] {
+					m.Set(k, 1)
+				}
+				return m, n
+			}
+
+			b.Run(fmt.Sprintf("load_%.2f/%s/hit", lf, impl.name), func(b *testing.B) {
+				m, n := fill()
+				b.ResetTimer()
+				for i := range b.N {
+					m.Lookup(present[i%n])
+				}
+				reportLoad(b, m, n)
+			})
+
+			b.Run(fmt.Sprintf("load_%.2f/%s/miss", lf, impl.name), func(b *testing.B) {
+				m, n := fill()
+				b.ResetTimer()
+				for i := range b.N {
+					m.Lookup(absent[i%len(absent)])
+				}
+				reportLoad(b, m, n)
+			})
+
+			b.Run(fmt.Sprintf("load_%.2f/%s/churn", lf, impl.name), func(b *testing.B) {
+				m, n := fill()
+				b.ResetTimer()
+				for i := range b.N {
+					k := present[i%n]
+					m.Remove(k)
+					m.Set(k, i)
+				}
+				reportLoad(b, m, n)
+			})
+		}
+	}
+}
+
+func reportLoad[K comparable](b *testing.B, m benchMap[K], n int) {
+	if c := m.Cap(); c > 0 {
+		b.ReportMetric(float64(n)/float64(c), "load")
+	}
+}
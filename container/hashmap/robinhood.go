@@ -0,0 +1,230 @@
+// UNSTABLE
+package hashmap
+
+import (
+	"iter"
+	"math/bits"
+)
+
+const (
+	robinHoodInitSlots = 8
+
+	// robinHoodMaxLoad is maximal load factor of RobinHood table
+	// as a fraction robinHoodMaxLoad / robinHoodLoadScale.
+	robinHoodMaxLoad   = 7
+	robinHoodLoadScale = 8
+)
+
+func NewRobinHood[K comparable, V any]() *RobinHood[K, V] {
+	return NewRobinHoodFunc[K, V](defaultHash[K](), defaultEqual[K])
+}
+
+// NewRobinHoodFunc returns RobinHood with custom hash and equality.
+func NewRobinHoodFunc[K any, V any](hash Hash[K], equal Equal[K]) *RobinHood[K, V] {
+	return &RobinHood[K, V]{
+		hash:  hash,
+		equal: equal,
+		slots: make([]robinHoodSlot[K, V], robinHoodInitSlots),
+	}
+}
+
+// RobinHood is open-addressing hashmap with linear probing and
+// Robin Hood displacement: inserted entry takes the slot of any
+// entry, which is closer to its home slot, so probe sequences
+// have low variance and lookups stop early. Removes use backward
+// shift instead of tombstones, so the table never degrades.
+// Table grows, when it is filled by 87.5%.
+type RobinHood[K any, V any] struct {
+	hash  Hash[K]
+	equal Equal[K]
+	slots []robinHoodSlot[K, V]
+	busy  int
+}
+
+// robinHoodSlot stores distance from home slot plus
+// one in dib, so zero dib means that slot is empty.
+type robinHoodSlot[K any, V any] struct {
+	dib uint32
+	k   K
+	v   V
+}
+
+// Len returns number of entries.
+func (m *RobinHood[K, V]) Len() int {
+	return m.busy
+}
+
+// Cap returns number of slots in the table.
+func (m *RobinHood[K, V]) Cap() int {
+	return len(m.slots)
+}
+
+func (m *RobinHood[K, V]) Lookup(key K) (V, bool) {
+	if i := m.lookup(key); i >= 0 {
+		return m.slots[i].v, true
+	}
+	return *new(V), false
+}
+
+func (m *RobinHood[K, V]) Remove(key K) bool {
+	i := m.lookup(key)
+	if i < 0 {
+		return false
+	}
+
+	mask := len(m.slots) - 1
+	for {
+		next := (i + 1) & mask
+		if m.slots[next].dib <= 1 {
+			break
+		}
+
+		m.slots[i] = m.slots[next]
+		m.slots[i].dib--
+		i = next
+	}
+
+	m.slots[i] = robinHoodSlot[K, V]{}
+	m.busy--
+	return true
+}
+
+// Insert inserts the entry, if there is no such key.
+func (m *RobinHood[K, V]) Insert(key K, value V) {
+	if m.lookup(key) < 0 {
+		m.insert(key, value)
+	}
+}
+
+// Set inserts the entry or overwrites value of the existing key.
+func (m *RobinHood[K, V]) Set(key K, value V) {
+	if i := m.lookup(key); i >= 0 {
+		m.slots[i].v = value
+		return
+	}
+
+	m.insert(key, value)
+}
+
+// LoadOrStore returns the existing value for the key, if present.
+// Otherwise, it inserts and returns the given value.
+func (m *RobinHood[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if i := m.lookup(key); i >= 0 {
+		return m.slots[i].v, true
+	}
+
+	m.insert(key, value)
+	return value, false
+}
+
+// All iterates over all entries in unspecified order.
+// The map must not be modified during iteration.
+func (m *RobinHood[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := range m.slots {
+			if m.slots[i].dib != 0 && !yield(m.slots[i].k, m.slots[i].v) {
+				return
+			}
+		}
+	}
+}
+
+func (m *RobinHood[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range m.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+func (m *RobinHood[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range m.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Clear removes all entries, but keeps allocated memory.
+func (m *RobinHood[K, V]) Clear() {
+	clear(m.slots)
+	m.busy = 0
+}
+
+// Clone returns a copy of the map, which shares nothing with it.
+func (m *RobinHood[K, V]) Clone() *RobinHood[K, V] {
+	clone := *m
+	clone.slots = append([]robinHoodSlot[K, V](nil), m.slots...)
+	return &clone
+}
+
+// Reserve grows the table, so n entries
+// can be stored without further growth.
+func (m *RobinHood[K, V]) Reserve(n int) {
+	need := n*robinHoodLoadScale/robinHoodMaxLoad + 1
+	if need <= len(m.slots) {
+		return
+	}
+
+	m.resize(1 << bits.Len(uint(need-1)))
+}
+
+func (m *RobinHood[K, V]) lookup(key K) int {
+	mask := len(m.slots) - 1
+	i := int(m.hash(key)) & mask
+	for dib := uint32(1); ; dib++ {
+		s := &m.slots[i]
+		if s.dib < dib {
+			return -1
+		}
+		if s.dib == dib && m.equal(s.k, key) {
+			return i
+		}
+		i = (i + 1) & mask
+	}
+}
+
+func (m *RobinHood[K, V]) insert(key K, value V) {
+	if (m.busy+1)*robinHoodLoadScale > len(m.slots)*robinHoodMaxLoad {
+		m.resize(2 * len(m.slots))
+	}
+
+	m.place(robinHoodSlot[K, V]{dib: 1, k: key, v: value})
+	m.busy++
+}
+
+// place puts entry, which key is not in the table,
+// swapping it with richer entries on its way.
+func (m *RobinHood[K, V]) place(s robinHoodSlot[K, V]) {
+	mask := len(m.slots) - 1
+	i := int(m.hash(s.k)) & mask
+	for {
+		curr := &m.slots[i]
+		if curr.dib == 0 {
+			*curr = s
+			return
+		}
+
+		if curr.dib < s.dib {
+			*curr, s = s, *curr
+		}
+
+		s.dib++
+		i = (i + 1) & mask
+	}
+}
+
+func (m *RobinHood[K, V]) resize(size int) {
+	old := m.slots
+	m.slots = make([]robinHoodSlot[K, V], size)
+	for _, s := range old {
+		if s.dib != 0 {
+			s.dib = 1
+			m.place(s)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package hashmap
+
+import (
+	"maps"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRobinHood(t *testing.T) {
+	m := NewRobinHood[int, int]()
+	et := make(map[int]int)
+
+	rnd := rand.New(rand.NewSource(42))
+	for range 200_000 {
+		k := rnd.Intn(20_000)
+		switch rnd.Intn(4) {
+		case 0, 1:
+			m.Set(k, k*2)
+			et[k] = k * 2
+		case 2:
+			_, ok := et[k]
+			require.Equal(t, ok, m.Remove(k))
+			delete(et, k)
+		case 3:
+			v, loaded := m.LoadOrStore(k, -k)
+			old, ok := et[k]
+			require.Equal(t, ok, loaded)
+			if ok {
+				require.Equal(t, old, v)
+			} else {
+				et[k] = -k
+			}
+		}
+	}
+
+	require.Equal(t, len(et), m.Len())
+	require.Equal(t, et, maps.Collect(m.All()))
+
+	for k, v := range et {
+		got, ok := m.Lookup(k)
+		require.True(t, ok)
+		require.Equal(t, v, got)
+	}
+
+	clone := m.Clone()
+	m.Clear()
+	require.Equal(t, 0, m.Len())
+	require.Equal(t, et, maps.Collect(clone.All()))
+}
+
+func TestRobinHood_Reserve(t *testing.T) {
+	m := NewRobinHood[string, int]()
+	m.Reserve(1000)
+
+	capacity := m.Cap()
+	for i := range 1000 {
+		m.Insert(string(rune(i)), i)
+	}
+	require.Equal(t, capacity, m.Cap(), "reserved map has grown")
+	require.Equal(t, 1000, m.Len())
+}
@@ -0,0 +1,39 @@
+package heap
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func BenchmarkPriorityQueues(b *testing.B) {
+	impls := []struct {
+		name string
+		new  func() PriorityQueue[int]
+	}{
+		{"binary", func() PriorityQueue[int] { return NewPriorityQueue(cmp.Compare[int]) }},
+		{"dary=4", func() PriorityQueue[int] { return NewDary(4, cmp.Compare[int]) }},
+		{"dary=8", func() PriorityQueue[int] { return NewDary(8, cmp.Compare[int]) }},
+		{"minmax", func() PriorityQueue[int] { return NewMinMax(cmp.Compare[int]) }},
+		{"pairing", func() PriorityQueue[int] { return NewPairing(cmp.Compare[int]) }},
+	}
+
+	for _, size := range []int{1 << 10, 1 << 16} {
+		keys := rand.New(rand.NewSource(42)).Perm(size)
+		for _, impl := range impls {
+			b.Run(fmt.Sprintf("%s/size=%d", impl.name, size), func(b *testing.B) {
+				q := impl.new()
+				for _, k := range keys {
+					q.Push(k)
+				}
+
+				i := 0
+				for b.Loop() {
+					q.Push(q.Pop() + keys[i%size])
+					i++
+				}
+			})
+		}
+	}
+}
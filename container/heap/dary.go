@@ -0,0 +1,88 @@
+package heap
+
+var _ PriorityQueue[struct{}] = new(DaryHeap[struct{}])
+
+// NewDary returns heap, which nodes have given number of
+// children. Larger arity makes the tree lower, so Push and
+// decrease of elements are faster, and Pop compares more
+// children on every level, but they share cache lines.
+func NewDary[T any](arity int, compareFunc func(T, T) int) *DaryHeap[T] {
+	if arity < 2 {
+		panic("heap arity must be at least 2")
+	}
+	return &DaryHeap[T]{comp: compareFunc, arity: arity}
+}
+
+type DaryHeap[T any] struct {
+	comp  func(T, T) int
+	arity int
+	data  []T
+}
+
+func (h *DaryHeap[T]) Min() T {
+	return h.data[0]
+}
+
+func (h *DaryHeap[T]) Size() int {
+	return len(h.data)
+}
+
+func (h *DaryHeap[T]) Empty() bool {
+	return len(h.data) == 0
+}
+
+func (h *DaryHeap[T]) Push(x T) {
+	h.data = append(h.data, x)
+	h.siftUp(len(h.data) - 1)
+}
+
+func (h *DaryHeap[T]) Pop() T {
+	m := h.data[0]
+	last := len(h.data) - 1
+	h.data[0] = h.data[last]
+	h.data[last] = *new(T)
+	h.data = h.data[:last]
+	h.siftDown(0)
+	return m
+}
+
+func (h *DaryHeap[T]) siftUp(i int) {
+	x := h.data[i]
+	for i > 0 {
+		p := (i - 1) / h.arity
+		if h.comp(x, h.data[p]) >= 0 {
+			break
+		}
+		h.data[i] = h.data[p]
+		i = p
+	}
+	h.data[i] = x
+}
+
+func (h *DaryHeap[T]) siftDown(i int) {
+	if len(h.data) == 0 {
+		return
+	}
+
+	x := h.data[i]
+	for {
+		first := h.arity*i + 1
+		if first >= len(h.data) {
+			break
+		}
+
+		j := first
+		for c := first + 1; c < min(first+h.arity, len(h.data)); c++ {
+			if h.comp(h.data[c], h.data[j]) < 0 {
+				j = c
+			}
+		}
+
+		if h.comp(x, h.data[j]) <= 0 {
+			break
+		}
+		h.data[i] = h.data[j]
+		i = j
+	}
+	h.data[i] = x
+}
@@ -0,0 +1,31 @@
+package heap
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaryHeap(t *testing.T) {
+	for _, arity := range []int{2, 3, 4, 8} {
+		h := NewDary(arity, cmp.Compare[int])
+		rnd := rand.New(rand.NewSource(42))
+
+		var et []int
+		for range 10_000 {
+			x := rnd.Intn(1000)
+			h.Push(x)
+			et = append(et, x)
+		}
+
+		slices.Sort(et)
+		for _, x := range et {
+			require.Equal(t, x, h.Min())
+			require.Equal(t, x, h.Pop())
+		}
+		require.True(t, h.Empty())
+	}
+}
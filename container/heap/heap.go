@@ -9,6 +9,10 @@ var _ Heap[struct{}] = new(heap[struct{}])
 
 func Make[T comparable](compareFunc func(T, T) int, elems []T) *heap[T] {
 	h := New(compareFunc)
+	h.q.data = elems
+	for i, x := range elems {
+		h.m[x] = i
+	}
 	h.q.heapify()
 	return h
 }
@@ -48,6 +52,7 @@ func (h *heap[T]) Empty() bool {
 }
 
 func (h *heap[T]) Push(x T) {
+	h.m[x] = h.q.Size()
 	h.q.Push(x)
 }
 
@@ -64,8 +69,10 @@ func (h *heap[T]) DecreaseKey(old, new T) bool {
 	}
 
 	h.q.data[idx] = new
+	delete(h.m, old)
+	h.m[new] = idx
 
-	c := h.q.comp(old, new)
+	c := h.q.comp(new, old)
 	switch {
 	case c < 0:
 		h.q.siftUp(idx)
@@ -0,0 +1,108 @@
+package heap
+
+// Handle refers to the element pushed into IndexedHeap.
+// It stays valid until the element is popped or removed.
+type Handle[T any] struct {
+	e *indexedEntry[T]
+}
+
+type indexedEntry[T any] struct {
+	value T
+	index int
+}
+
+func NewIndexed[T any](compareFunc func(T, T) int) *IndexedHeap[T] {
+	h := new(IndexedHeap[T])
+	h.q.init(func(a, b *indexedEntry[T]) int {
+		return compareFunc(a.value, b.value)
+	}, nil, h.swap)
+	return h
+}
+
+// IndexedHeap is binary heap, which elements are addressed by
+// handles returned from Push, so they can be updated or removed
+// in O(log n). Unlike Heap, it doesn't compare elements for
+// equality, so it may contain equal elements.
+type IndexedHeap[T any] struct {
+	q pq[*indexedEntry[T]]
+}
+
+func (h *IndexedHeap[T]) Min() T {
+	return h.q.Min().value
+}
+
+func (h *IndexedHeap[T]) Size() int {
+	return h.q.Size()
+}
+
+func (h *IndexedHeap[T]) Empty() bool {
+	return h.q.Empty()
+}
+
+func (h *IndexedHeap[T]) Push(x T) Handle[T] {
+	e := &indexedEntry[T]{value: x, index: h.q.Size()}
+	h.q.Push(e)
+	return Handle[T]{e}
+}
+
+func (h *IndexedHeap[T]) Pop() T {
+	e := h.q.Pop()
+	e.index = -1
+	return e.value
+}
+
+// Peek returns the element referred by handle.
+func (h *IndexedHeap[T]) Peek(handle Handle[T]) T {
+	h.check(handle)
+	return handle.e.value
+}
+
+// Update replaces the element referred by handle.
+func (h *IndexedHeap[T]) Update(handle Handle[T], x T) {
+	h.check(handle)
+	handle.e.value = x
+	h.fix(handle.e)
+}
+
+// Fix restores heap order after the element
+// referred by handle has been changed in place.
+func (h *IndexedHeap[T]) Fix(handle Handle[T]) {
+	h.check(handle)
+	h.fix(handle.e)
+}
+
+// Remove removes the element referred by handle. It
+// returns false, if it has been already removed.
+func (h *IndexedHeap[T]) Remove(handle Handle[T]) bool {
+	i := handle.e.index
+	if i < 0 {
+		return false
+	}
+
+	last := h.q.Size() - 1
+	h.q.swap(i, last)
+	h.q.data = h.q.data[:last]
+	handle.e.index = -1
+
+	if i < last {
+		h.fix(h.q.data[i])
+	}
+	return true
+}
+
+func (h *IndexedHeap[T]) fix(e *indexedEntry[T]) {
+	h.q.siftUp(e.index)
+	h.q.siftDown(e.index)
+}
+
+func (h *IndexedHeap[T]) check(handle Handle[T]) {
+	if handle.e.index < 0 {
+		panic("handle of removed element")
+	}
+}
+
+func (h *IndexedHeap[T]) swap(i, j int) {
+	h.q.swapElems(i, j)
+	h.q.data[i].index = i
+	h.q.data[j].index = j
+}
@@ -0,0 +1,103 @@
+package heap
+
+import (
+	"cmp"
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexedHeap(t *testing.T) {
+	h := NewIndexed(cmp.Compare[int])
+	values := map[Handle[int]]int{}
+
+	rnd := rand.New(rand.NewSource(42))
+	for range 100_000 {
+		switch rnd.Intn(5) {
+		case 0, 1:
+			x := rnd.Intn(100)
+			values[h.Push(x)] = x
+		case 2:
+			if h.Empty() {
+				continue
+			}
+			x := h.Pop()
+			require.Equal(t, minValue(values), x)
+			for handle := range values {
+				if handle.e.index < 0 {
+					delete(values, handle)
+				}
+			}
+		case 3:
+			for handle := range values {
+				x := rnd.Intn(100)
+				h.Update(handle, x)
+				values[handle] = x
+				break
+			}
+		case 4:
+			for handle, v := range values {
+				require.Equal(t, v, h.Peek(handle))
+				require.True(t, h.Remove(handle))
+				require.False(t, h.Remove(handle))
+				delete(values, handle)
+				break
+			}
+		}
+
+		require.Equal(t, len(values), h.Size())
+		if !h.Empty() {
+			require.Equal(t, minValue(values), h.Min())
+		}
+	}
+}
+
+func TestIndexedHeap_Fix(t *testing.T) {
+	h := NewIndexed(func(a, b *int) int { return cmp.Compare(*a, *b) })
+	xs := []int{5, 3, 8, 1}
+	handles := make([]Handle[*int], len(xs))
+	for i := range xs {
+		handles[i] = h.Push(&xs[i])
+	}
+
+	xs[2] = 0
+	h.Fix(handles[2])
+	require.Equal(t, 0, *h.Pop())
+
+	require.Panics(t, func() { h.Peek(handles[2]) })
+	require.False(t, h.Remove(handles[2]))
+
+	var sorted []int
+	for !h.Empty() {
+		sorted = append(sorted, *h.Pop())
+	}
+	require.Equal(t, []int{1, 3, 5}, sorted)
+}
+
+func TestHeap_DecreaseKey(t *testing.T) {
+	h := New(cmp.Compare[int])
+	for _, x := range []int{10, 20, 30, 40} {
+		h.Push(x)
+	}
+
+	require.True(t, h.DecreaseKey(40, 5))
+	require.True(t, h.DecreaseKey(10, 35))
+	require.False(t, h.DecreaseKey(10, 1))
+
+	var sorted []int
+	for !h.Empty() {
+		sorted = append(sorted, h.Pop())
+	}
+	require.Equal(t, []int{5, 20, 30, 35}, sorted)
+
+	h = Make(cmp.Compare[int], []int{4, 2, 3, 1})
+	require.True(t, h.DecreaseKey(4, 0))
+	require.Equal(t, 0, h.Pop())
+}
+
+func minValue[K comparable](values map[K]int) int {
+	return slices.Min(slices.Collect(maps.Values(values)))
+}
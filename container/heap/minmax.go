@@ -0,0 +1,156 @@
+package heap
+
+import "math/bits"
+
+var _ PriorityQueue[struct{}] = new(MinMaxHeap[struct{}])
+
+func NewMinMax[T any](compareFunc func(T, T) int) *MinMaxHeap[T] {
+	return &MinMaxHeap[T]{comp: compareFunc}
+}
+
+// MinMaxHeap is double-ended priority queue. Its levels
+// alternate: elements on even levels are minimal in their
+// subtrees, and on odd levels are maximal, so both Min and
+// Max are O(1), and PopMin and PopMax are O(log n).
+type MinMaxHeap[T any] struct {
+	comp func(T, T) int
+	data []T
+}
+
+func (h *MinMaxHeap[T]) Min() T {
+	return h.data[0]
+}
+
+// Max returns the maximal element.
+func (h *MinMaxHeap[T]) Max() T {
+	return h.data[h.maxIndex()]
+}
+
+func (h *MinMaxHeap[T]) Size() int {
+	return len(h.data)
+}
+
+func (h *MinMaxHeap[T]) Empty() bool {
+	return len(h.data) == 0
+}
+
+func (h *MinMaxHeap[T]) Push(x T) {
+	h.data = append(h.data, x)
+	h.bubbleUp(len(h.data) - 1)
+}
+
+// Pop is the same as PopMin.
+func (h *MinMaxHeap[T]) Pop() T {
+	return h.PopMin()
+}
+
+// PopMin removes and returns the minimal element.
+func (h *MinMaxHeap[T]) PopMin() T {
+	return h.remove(0)
+}
+
+// PopMax removes and returns the maximal element.
+func (h *MinMaxHeap[T]) PopMax() T {
+	return h.remove(h.maxIndex())
+}
+
+func (h *MinMaxHeap[T]) maxIndex() int {
+	switch {
+	case len(h.data) == 1:
+		return 0
+	case len(h.data) == 2 || !h.before(2, 1, false):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (h *MinMaxHeap[T]) remove(i int) T {
+	x := h.data[i]
+	last := len(h.data) - 1
+	h.data[i] = h.data[last]
+	h.data[last] = *new(T)
+	h.data = h.data[:last]
+	if i < last {
+		h.pushDown(i)
+	}
+	return x
+}
+
+func (h *MinMaxHeap[T]) bubbleUp(i int) {
+	if i == 0 {
+		return
+	}
+
+	// parent is on the opposite level, so element
+	// may go up only through the levels of one kind
+	min := isMinLevel(i)
+	if p := (i - 1) / 2; h.before(i, p, !min) {
+		h.swap(i, p)
+		i, min = p, !min
+	}
+
+	for i > 2 {
+		g := ((i-1)/2 - 1) / 2
+		if !h.before(i, g, min) {
+			break
+		}
+		h.swap(i, g)
+		i = g
+	}
+}
+
+func (h *MinMaxHeap[T]) pushDown(i int) {
+	min := isMinLevel(i)
+	for {
+		m, grandchild := h.extremeDescendant(i, min)
+		if m < 0 || !h.before(m, i, min) {
+			return
+		}
+
+		h.swap(m, i)
+		if !grandchild {
+			return
+		}
+
+		if p := (m - 1) / 2; h.before(p, m, min) {
+			h.swap(m, p)
+		}
+		i = m
+	}
+}
+
+// extremeDescendant returns the minimal, or maximal, if
+// !min, of children and grandchildren of i, or -1, if i
+// is a leaf.
+func (h *MinMaxHeap[T]) extremeDescendant(i int, min bool) (int, bool) {
+	m, grandchild := -1, false
+	for c := 2*i + 1; c <= 2*i+2 && c < len(h.data); c++ {
+		if m < 0 || h.before(c, m, min) {
+			m, grandchild = c, false
+		}
+		for g := 2*c + 1; g <= 2*c+2 && g < len(h.data); g++ {
+			if h.before(g, m, min) {
+				m, grandchild = g, true
+			}
+		}
+	}
+	return m, grandchild
+}
+
+// before reports whether i-th element goes strictly
+// before j-th one in order of the level.
+func (h *MinMaxHeap[T]) before(i, j int, min bool) bool {
+	if min {
+		return h.comp(h.data[i], h.data[j]) < 0
+	}
+	return h.comp(h.data[i], h.data[j]) > 0
+}
+
+func (h *MinMaxHeap[T]) swap(i, j int) {
+	h.data[i], h.data[j] = h.data[j], h.data[i]
+}
+
+func isMinLevel(i int) bool {
+	return bits.Len(uint(i+1))%2 == 1
+}
@@ -0,0 +1,43 @@
+package heap
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinMaxHeap(t *testing.T) {
+	h := NewMinMax(cmp.Compare[int])
+	var et []int
+
+	rnd := rand.New(rand.NewSource(42))
+	for range 100_000 {
+		switch rnd.Intn(4) {
+		case 0, 1:
+			x := rnd.Intn(1000)
+			h.Push(x)
+			et = append(et, x)
+		case 2:
+			if len(et) > 0 {
+				i := slices.Index(et, slices.Min(et))
+				require.Equal(t, et[i], h.PopMin())
+				et = slices.Delete(et, i, i+1)
+			}
+		case 3:
+			if len(et) > 0 {
+				i := slices.Index(et, slices.Max(et))
+				require.Equal(t, et[i], h.PopMax())
+				et = slices.Delete(et, i, i+1)
+			}
+		}
+
+		require.Equal(t, len(et), h.Size())
+		if len(et) > 0 {
+			require.Equal(t, slices.Min(et), h.Min())
+			require.Equal(t, slices.Max(et), h.Max())
+		}
+	}
+}
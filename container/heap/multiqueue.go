@@ -0,0 +1,190 @@
+package heap
+
+import (
+	"context"
+	"math/rand/v2"
+	"runtime"
+
+	"github.com/nikmy/algo/syncx"
+	"github.com/nikmy/algo/syncx/atomx"
+)
+
+// NewMultiQueue returns MultiQueue of given number of heaps.
+// Non-positive number means twice the GOMAXPROCS.
+func NewMultiQueue[T any](queues int, compareFunc func(T, T) int) *MultiQueue[T] {
+	if queues <= 0 {
+		queues = 2 * runtime.GOMAXPROCS(0)
+	}
+
+	q := &MultiQueue[T]{
+		comp:   compareFunc,
+		queues: make([]lockedQueue[T], queues),
+		wake:   make(chan struct{}, 1),
+	}
+	for i := range q.queues {
+		q.queues[i].q.init(compareFunc, nil, q.queues[i].q.swapElems)
+	}
+	return q
+}
+
+// MultiQueue is relaxed priority queue, which is safe for
+// concurrent use. It consists of a few heaps under separate
+// locks. Push puts the element into a random heap, and pop
+// takes the minimum of the better of two random heaps, so
+// goroutines rarely contend for the same lock.
+//
+// The order is relaxed: popped element is not necessarily the
+// minimal one, but the expected number of smaller elements in
+// the queue, i.e. rank error, is O(number of heaps), which is
+// about twice the number of heaps in practice. MultiQueue of
+// a single heap is strict, but serializes all operations.
+type MultiQueue[T any] struct {
+	comp   func(T, T) int
+	queues []lockedQueue[T]
+	size   atomx.Int64
+
+	waiters atomx.Int64
+	wake    chan struct{}
+}
+
+type lockedQueue[T any] struct {
+	lock syncx.Mutex
+	q    pq[T]
+
+	// top is a copy of the minimum, so it can
+	// be compared without taking the lock
+	top atomx.Pointer[T]
+	_   [64]byte
+}
+
+// Len returns approximate number of elements.
+func (q *MultiQueue[T]) Len() int {
+	return int(max(0, q.size.Load()))
+}
+
+func (q *MultiQueue[T]) Push(x T) {
+	for {
+		lq := &q.queues[rand.IntN(len(q.queues))]
+		if !lq.lock.TryLock() {
+			continue
+		}
+
+		lq.q.Push(x)
+		if lq.q.Size() == 1 || q.comp(x, lq.q.Min()) <= 0 {
+			lq.top.Store(&x)
+		}
+		lq.lock.Unlock()
+		break
+	}
+
+	q.size.Add(1)
+	q.signal()
+}
+
+// TryPopMin removes and returns a small element.
+// It returns false, if all heaps are empty.
+func (q *MultiQueue[T]) TryPopMin() (T, bool) {
+	for range len(q.queues) {
+		lq := q.choose()
+		if lq == nil {
+			break
+		}
+
+		if !lq.lock.TryLock() {
+			continue
+		}
+
+		x, ok := lq.pop()
+		lq.lock.Unlock()
+		if ok {
+			q.size.Add(-1)
+			return x, true
+		}
+	}
+
+	// two random heaps may be empty, while others are not
+	for i := range q.queues {
+		lq := &q.queues[i]
+		lq.lock.Lock()
+		x, ok := lq.pop()
+		lq.lock.Unlock()
+		if ok {
+			q.size.Add(-1)
+			return x, true
+		}
+	}
+
+	return *new(T), false
+}
+
+// PopMin is like TryPopMin, but waits for an element until
+// ctx is done. Then it returns the context error.
+func (q *MultiQueue[T]) PopMin(ctx context.Context) (T, error) {
+	q.waiters.Add(1)
+	defer q.waiters.Add(-1)
+
+	for {
+		if x, ok := q.TryPopMin(); ok {
+			// wake the next waiter, if there is more work
+			if q.size.Load() > 0 {
+				q.signal()
+			}
+			return x, nil
+		}
+
+		select {
+		case <-q.wake:
+		case <-ctx.Done():
+			return *new(T), ctx.Err()
+		}
+	}
+}
+
+// signal wakes one of goroutines waiting in PopMin. Waiters
+// are counted before they check the heaps, so a waiter either
+// sees the pushed element or is woken up.
+func (q *MultiQueue[T]) signal() {
+	if q.waiters.Load() == 0 {
+		return
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// choose returns the heap with the smaller top of two random
+// heaps, or nil, if both of them are empty.
+func (q *MultiQueue[T]) choose() *lockedQueue[T] {
+	a := &q.queues[rand.IntN(len(q.queues))]
+	b := &q.queues[rand.IntN(len(q.queues))]
+
+	ta, tb := a.top.Load(), b.top.Load()
+	switch {
+	case ta == nil && tb == nil:
+		return nil
+	case ta == nil:
+		return b
+	case tb == nil || q.comp(*ta, *tb) <= 0:
+		return a
+	default:
+		return b
+	}
+}
+
+// pop removes the minimum. Lock must be held.
+func (lq *lockedQueue[T]) pop() (T, bool) {
+	if lq.q.Empty() {
+		return *new(T), false
+	}
+
+	x := lq.q.Pop()
+	if lq.q.Empty() {
+		lq.top.Store(nil)
+	} else {
+		top := lq.q.Min()
+		lq.top.Store(&top)
+	}
+	return x, true
+}
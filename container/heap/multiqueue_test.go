@@ -0,0 +1,135 @@
+package heap
+
+import (
+	"cmp"
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikmy/algo/syncx/atomx"
+	"github.com/nikmy/algo/testx/faulty"
+	"github.com/nikmy/algo/testx/synctest"
+)
+
+func TestMultiQueue_Safety(t *testing.T) {
+	q := NewMultiQueue(4, cmp.Compare[int])
+
+	var pushed, popped atomx.Int64
+	push := synctest.Operation{
+		Runner: func() {
+			x := rand.Intn(1000)
+			q.Push(x)
+			pushed.Add(int64(x))
+		},
+		Actors: 2,
+	}
+
+	tryPop := synctest.Operation{
+		Runner: func() {
+			if x, ok := q.TryPopMin(); ok {
+				popped.Add(int64(x))
+			}
+		},
+		Actors: 1,
+	}
+
+	pop := synctest.Operation{
+		Runner: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+			if x, err := q.PopMin(ctx); err == nil {
+				popped.Add(int64(x))
+			}
+		},
+		Actors: 1,
+	}
+
+	c := faulty.NewController(t, 42)
+	c.SetFaultProbability(0.3)
+
+	synctest.Stress(t, c.FaultInjector, 10_000, push, tryPop, pop)
+
+	for {
+		x, ok := q.TryPopMin()
+		if !ok {
+			break
+		}
+		popped.Add(int64(x))
+	}
+	require.Equal(t, pushed.Load(), popped.Load())
+	require.Zero(t, q.Len())
+}
+
+func TestMultiQueue_RankError(t *testing.T) {
+	const queues, n = 8, 100_000
+
+	q := NewMultiQueue(queues, cmp.Compare[int])
+	for _, x := range rand.New(rand.NewSource(42)).Perm(n) {
+		q.Push(x)
+	}
+
+	// all elements are distinct, so rank error is the number
+	// of smaller elements, which are still in the queue
+	popped := make([]bool, n)
+	smallest, total := 0, 0
+	for range n {
+		x, ok := q.TryPopMin()
+		require.True(t, ok)
+
+		popped[x] = true
+		for smallest < n && popped[smallest] {
+			smallest++
+		}
+		total += x - smallest
+	}
+
+	require.Less(t, float64(total)/n, float64(4*queues))
+}
+
+func TestMultiQueue_Strict(t *testing.T) {
+	q := NewMultiQueue(1, cmp.Compare[int])
+	for _, x := range rand.New(rand.NewSource(42)).Perm(1000) {
+		q.Push(x)
+	}
+
+	for i := range 1000 {
+		x, ok := q.TryPopMin()
+		require.True(t, ok)
+		require.Equal(t, i, x)
+	}
+
+	_, ok := q.TryPopMin()
+	require.False(t, ok)
+}
+
+func TestMultiQueue_PopMin(t *testing.T) {
+	q := NewMultiQueue(4, cmp.Compare[int])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := q.PopMin(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	results := make(chan int)
+	for range 3 {
+		go func() {
+			x, err := q.PopMin(context.Background())
+			require.NoError(t, err)
+			results <- x
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	for i := range 3 {
+		q.Push(i)
+	}
+
+	sum := 0
+	for range 3 {
+		sum += <-results
+	}
+	require.Equal(t, 3, sum)
+}
@@ -0,0 +1,204 @@
+package heap
+
+var _ PriorityQueue[struct{}] = new(PairingHeap[struct{}])
+
+// PairingHandle refers to the element pushed into PairingHeap.
+// It stays valid until the element is popped or deleted, also
+// after the heap is melded into another one.
+type PairingHandle[T any] struct {
+	n *pairingNode[T]
+}
+
+// pairingNode is stored in the child-sibling form. Prev is the
+// previous sibling, or the parent for the leftmost child.
+type pairingNode[T any] struct {
+	value T
+	child *pairingNode[T]
+	next  *pairingNode[T]
+	prev  *pairingNode[T]
+	live  bool
+}
+
+func NewPairing[T any](compareFunc func(T, T) int) *PairingHeap[T] {
+	return &PairingHeap[T]{comp: compareFunc}
+}
+
+// PairingHeap is mergeable heap with O(1) Push and Meld,
+// amortized O(log n) Pop and Delete, and amortized o(log n)
+// decrease of the element, which is O(1) in practice.
+type PairingHeap[T any] struct {
+	comp func(T, T) int
+	root *pairingNode[T]
+	size int
+}
+
+func (h *PairingHeap[T]) Min() T {
+	return h.root.value
+}
+
+func (h *PairingHeap[T]) Size() int {
+	return h.size
+}
+
+func (h *PairingHeap[T]) Empty() bool {
+	return h.size == 0
+}
+
+func (h *PairingHeap[T]) Push(x T) {
+	h.PushHandle(x)
+}
+
+// PushHandle pushes x and returns its handle.
+func (h *PairingHeap[T]) PushHandle(x T) PairingHandle[T] {
+	n := &pairingNode[T]{value: x, live: true}
+	h.root = h.link(h.root, n)
+	h.size++
+	return PairingHandle[T]{n}
+}
+
+func (h *PairingHeap[T]) Pop() T {
+	root := h.root
+	h.root = h.mergePairs(root.child)
+	h.size--
+
+	*root = pairingNode[T]{value: root.value}
+	return root.value
+}
+
+// Peek returns the element referred by handle.
+func (h *PairingHeap[T]) Peek(handle PairingHandle[T]) T {
+	h.check(handle)
+	return handle.n.value
+}
+
+// Update replaces the element referred by handle. Decrease
+// takes amortized O(1) in practice, and increase is as
+// expensive as Delete.
+func (h *PairingHeap[T]) Update(handle PairingHandle[T], x T) {
+	h.check(handle)
+
+	n := handle.n
+	decrease := h.comp(x, n.value) <= 0
+	n.value = x
+	if n == h.root {
+		if !decrease {
+			rest := h.mergePairs(n.child)
+			n.child = nil
+			h.root = h.link(rest, n)
+		}
+		return
+	}
+
+	h.cut(n)
+	if !decrease {
+		h.root = h.link(h.root, h.mergePairs(n.child))
+		n.child = nil
+	}
+	h.root = h.link(h.root, n)
+}
+
+// Delete removes the element referred by handle. It
+// returns false, if it has been already removed.
+func (h *PairingHeap[T]) Delete(handle PairingHandle[T]) bool {
+	n := handle.n
+	if !n.live {
+		return false
+	}
+
+	if n == h.root {
+		h.Pop()
+		return true
+	}
+
+	h.cut(n)
+	h.root = h.link(h.root, h.mergePairs(n.child))
+	h.size--
+	*n = pairingNode[T]{value: n.value}
+	return true
+}
+
+// Meld moves all elements of other into h in O(1).
+// Both heaps must have the same order of elements.
+func (h *PairingHeap[T]) Meld(other *PairingHeap[T]) {
+	h.root = h.link(h.root, other.root)
+	h.size += other.size
+	other.root = nil
+	other.size = 0
+}
+
+// link makes the root with greater element the leftmost
+// child of another one. Both nodes must have no siblings.
+func (h *PairingHeap[T]) link(a, b *pairingNode[T]) *pairingNode[T] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	if h.comp(b.value, a.value) < 0 {
+		a, b = b, a
+	}
+
+	b.next = a.child
+	if a.child != nil {
+		a.child.prev = b
+	}
+	b.prev = a
+	a.child = b
+	return a
+}
+
+// mergePairs links siblings starting from first in pairs
+// from left to right, and then links the results from right
+// to left, which gives amortized O(log n) of Pop.
+func (h *PairingHeap[T]) mergePairs(first *pairingNode[T]) *pairingNode[T] {
+	var merged *pairingNode[T]
+	for first != nil {
+		a, b := first, first.next
+		if b == nil {
+			first = nil
+		} else {
+			first = b.next
+			b.next, b.prev = nil, nil
+		}
+		a.next, a.prev = nil, nil
+
+		pair := h.link(a, b)
+		pair.next = merged
+		merged = pair
+	}
+
+	var root *pairingNode[T]
+	for merged != nil {
+		next := merged.next
+		merged.next = nil
+		root = h.link(root, merged)
+		merged = next
+	}
+
+	if root != nil {
+		root.prev = nil
+	}
+	return root
+}
+
+// cut detaches subtree of n from its parent.
+func (h *PairingHeap[T]) cut(n *pairingNode[T]) {
+	if n.prev.child == n {
+		n.prev.child = n.next
+	} else {
+		n.prev.next = n.next
+	}
+
+	if n.next != nil {
+		n.next.prev = n.prev
+	}
+	n.next, n.prev = nil, nil
+}
+
+func (h *PairingHeap[T]) check(handle PairingHandle[T]) {
+	if !handle.n.live {
+		panic("handle of removed element")
+	}
+}
@@ -0,0 +1,75 @@
+package heap
+
+import (
+	"cmp"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPairingHeap(t *testing.T) {
+	h := NewPairing(cmp.Compare[int])
+	values := map[PairingHandle[int]]int{}
+
+	rnd := rand.New(rand.NewSource(42))
+	for range 100_000 {
+		switch rnd.Intn(5) {
+		case 0, 1:
+			x := rnd.Intn(100)
+			values[h.PushHandle(x)] = x
+		case 2:
+			if h.Empty() {
+				continue
+			}
+			require.Equal(t, minValue(values), h.Pop())
+			for handle := range values {
+				if !handle.n.live {
+					delete(values, handle)
+				}
+			}
+		case 3:
+			for handle := range values {
+				x := rnd.Intn(100)
+				h.Update(handle, x)
+				values[handle] = x
+				break
+			}
+		case 4:
+			for handle, v := range values {
+				require.Equal(t, v, h.Peek(handle))
+				require.True(t, h.Delete(handle))
+				require.False(t, h.Delete(handle))
+				delete(values, handle)
+				break
+			}
+		}
+
+		require.Equal(t, len(values), h.Size())
+		if !h.Empty() {
+			require.Equal(t, minValue(values), h.Min())
+		}
+	}
+}
+
+func TestPairingHeap_Meld(t *testing.T) {
+	a, b := NewPairing(cmp.Compare[int]), NewPairing(cmp.Compare[int])
+	for i := range 100 {
+		a.Push(2 * i)
+	}
+	handle := b.PushHandle(1000)
+	for i := range 100 {
+		b.Push(2*i + 1)
+	}
+
+	a.Meld(b)
+	require.True(t, b.Empty())
+	require.Equal(t, 201, a.Size())
+
+	a.Update(handle, -1)
+	require.Equal(t, -1, a.Pop())
+	for i := range 200 {
+		require.Equal(t, i, a.Pop())
+	}
+	require.True(t, a.Empty())
+}
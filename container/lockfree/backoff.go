@@ -0,0 +1,28 @@
+package lockfree
+
+import "runtime"
+
+const (
+	backoffSpinSteps  = 6
+	backoffYieldSteps = 10
+)
+
+// backoff is exponential backoff for contended CAS loops.
+// It spins for 2^step iterations first, and yields the
+// processor after backoffSpinSteps failed attempts.
+type backoff struct {
+	step uint32
+}
+
+func (b *backoff) wait() {
+	if b.step <= backoffSpinSteps {
+		for i := 0; i < 1<<b.step; i++ {
+		}
+	} else {
+		runtime.Gosched()
+	}
+
+	if b.step <= backoffYieldSteps {
+		b.step++
+	}
+}
@@ -1,6 +1,7 @@
 package lockfree
 
 import (
+	"fmt"
 	"runtime"
 	"testing"
 	"time"
@@ -117,3 +118,60 @@ func benchBuffer(buf bufferImpl) (time.Duration, time.Duration, int, int) {
 
 	return elapsedP, elapsedC, produced, consumed
 }
+
+func BenchmarkRing_CompareWithChannel(b *testing.B) {
+	compareParSeq(b, func(size uint32) bufferImpl { return NewRing[int](size) })
+}
+
+func BenchmarkRing_MPMC(b *testing.B) {
+	for _, size := range []uint32{64, 1024} {
+		b.Run(fmt.Sprintf("channel_%d", size), func(b *testing.B) {
+			benchMPMC(b, make(chanBuf, size))
+		})
+		b.Run(fmt.Sprintf("ring_%d", size), func(b *testing.B) {
+			benchMPMC(b, NewRing[int](size))
+		})
+	}
+}
+
+func BenchmarkRing_Batch(b *testing.B) {
+	for _, batch := range []int{1, 8, 64} {
+		b.Run(fmt.Sprintf("batch_%d", batch), func(b *testing.B) {
+			ring := NewRing[int](1024)
+			b.SetParallelism(4)
+			b.RunParallel(func(pb *testing.PB) {
+				buf := make([]int, batch)
+				producer := true
+				for pb.Next() {
+					if producer {
+						ring.TryProduceBatch(buf)
+					} else {
+						ring.TryConsumeBatch(buf)
+					}
+					producer = !producer
+				}
+			})
+		})
+	}
+}
+
+// benchMPMC makes every goroutine to alternate
+// between producing and consuming, so the buffer
+// is accessed by many producers and consumers at
+// the same time.
+func benchMPMC(b *testing.B, buf bufferImpl) {
+	b.SetParallelism(4)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var x int
+		producer := true
+		for pb.Next() {
+			if producer {
+				buf.TryProduce(x)
+			} else {
+				buf.TryConsume(&x)
+			}
+			producer = !producer
+		}
+	})
+}
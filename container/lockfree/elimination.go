@@ -0,0 +1,68 @@
+package lockfree
+
+import (
+	"math/rand/v2"
+	"runtime"
+
+	"github.com/nikmy/algo/syncx/atomx"
+)
+
+// eliminationSpins is the number of checks pusher makes
+// before withdrawing its offer from elimination slot.
+const eliminationSpins = 32
+
+// eliminationArray lets concurrent Push and Pop cancel
+// each other out without touching the top of the stack.
+// Contended pusher publishes an offer in random slot and
+// waits for a while, contended popper takes any offer it
+// finds. Offers are allocated per exchange, so slot CAS
+// can not suffer from ABA.
+type eliminationArray[T any] []eliminationSlot[T]
+
+type eliminationSlot[T any] struct {
+	offer atomx.Pointer[eliminationOffer[T]]
+	_     [56]byte
+}
+
+type eliminationOffer[T any] struct {
+	elem T
+}
+
+func (a eliminationArray[T]) push(x T) bool {
+	if len(a) == 0 {
+		return false
+	}
+
+	slot := &a[rand.N(len(a))]
+	offer := &eliminationOffer[T]{elem: x}
+	if !slot.offer.CompareAndSwap(nil, offer) {
+		return false
+	}
+
+	for i := 0; i < eliminationSpins; i++ {
+		if slot.offer.Load() != offer {
+			return true
+		}
+		if i >= eliminationSpins/2 {
+			runtime.Gosched()
+		}
+	}
+
+	// withdraw fails only if popper has taken the offer
+	return !slot.offer.CompareAndSwap(offer, nil)
+}
+
+func (a eliminationArray[T]) pop(x *T) bool {
+	if len(a) == 0 {
+		return false
+	}
+
+	slot := &a[rand.N(len(a))]
+	offer := slot.offer.Load()
+	if offer == nil || !slot.offer.CompareAndSwap(offer, nil) {
+		return false
+	}
+
+	*x = offer.elem
+	return true
+}
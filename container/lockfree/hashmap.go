@@ -0,0 +1,295 @@
+package lockfree
+
+import (
+	"math/bits"
+
+	"github.com/dolthub/maphash"
+
+	"github.com/nikmy/algo/syncx/atomx"
+)
+
+const (
+	hashMapInitBuckets = 16
+	hashMapMaxLoad     = 2
+)
+
+func NewHashMap[K comparable, V any]() *HashMap[K, V] {
+	m := &HashMap[K, V]{
+		hasher: maphash.NewHasher[K](),
+		head:   &hashNode[K, V]{},
+	}
+	m.head.next.Store(&hashLink[K, V]{})
+
+	t := &hashTable[K, V]{slots: make([]atomx.Pointer[hashNode[K, V]], hashMapInitBuckets)}
+	t.slots[0].Store(m.head)
+	m.table.Store(t)
+
+	return m
+}
+
+// HashMap is lock-free hash map based on split-ordered
+// lists by Shalev and Shavit. All entries are kept in one
+// lock-free linked list, sorted by bit-reversed hashes, and
+// buckets are shortcuts into this list. When the table grows,
+// no entry is moved: new bucket is initialized lazily by
+// inserting dummy node into the list, which splits parent
+// bucket in two halves.
+//
+// All operations are lock-free. Load may initialize buckets
+// lazily, i.e. insert dummy nodes and unlink deleted ones.
+type HashMap[K comparable, V any] struct {
+	hasher maphash.Hasher[K]
+	head   *hashNode[K, V]
+	table  atomx.Pointer[hashTable[K, V]]
+	count  atomx.Int64
+}
+
+type hashTable[K comparable, V any] struct {
+	slots []atomx.Pointer[hashNode[K, V]]
+}
+
+// hashNode is either dummy node, which starts bucket, or
+// regular node, which holds an entry. Regular node is
+// logically deleted, when its value is nil.
+type hashNode[K comparable, V any] struct {
+	soKey uint64
+	key   K
+	value atomx.Pointer[V]
+	next  atomx.Pointer[hashLink[K, V]]
+}
+
+// hashLink is immutable markable reference. The node,
+// which next link is marked, is going to be unlinked.
+type hashLink[K comparable, V any] struct {
+	node   *hashNode[K, V]
+	marked bool
+}
+
+func (n *hashNode[K, V]) isDummy() bool {
+	return n.soKey&1 == 0
+}
+
+func (n *hashNode[K, V]) isDeleted() bool {
+	return !n.isDummy() && n.value.Load() == nil
+}
+
+func (n *hashNode[K, V]) mark() *hashLink[K, V] {
+	for {
+		link := n.next.Load()
+		if link.marked {
+			return link
+		}
+		if n.next.CompareAndSwap(link, &hashLink[K, V]{node: link.node, marked: true}) {
+			return link
+		}
+	}
+}
+
+// Len returns number of entries in the map.
+func (m *HashMap[K, V]) Len() int {
+	return int(m.count.Load())
+}
+
+func (m *HashMap[K, V]) Load(key K) (V, bool) {
+	h := m.hasher.Hash(key)
+	so := regularKey(h)
+
+	for curr := m.bucket(h).next.Load().node; curr != nil; curr = curr.next.Load().node {
+		if curr.soKey > so {
+			break
+		}
+		if curr.soKey != so || curr.key != key {
+			continue
+		}
+		if v := curr.value.Load(); v != nil {
+			return *v, true
+		}
+	}
+
+	return *new(V), false
+}
+
+func (m *HashMap[K, V]) Store(key K, value V) {
+	m.store(key, &value, true)
+}
+
+// LoadOrStore returns the existing value for the key, if present.
+// Otherwise, it stores and returns the given value.
+func (m *HashMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if old := m.store(key, &value, false); old != nil {
+		return *old, true
+	}
+	return value, false
+}
+
+// Delete removes the key and reports whether it was present.
+func (m *HashMap[K, V]) Delete(key K) bool {
+	h := m.hasher.Hash(key)
+	head, so := m.bucket(h), regularKey(h)
+
+	for {
+		prev, link, found := m.find(head, so, key)
+		if !found {
+			return false
+		}
+
+		curr := link.node
+		old := curr.value.Load()
+		if old == nil {
+			continue
+		}
+
+		if !curr.value.CompareAndSwap(old, nil) {
+			continue
+		}
+
+		m.count.Add(-1)
+
+		next := curr.mark()
+		prev.next.CompareAndSwap(link, &hashLink[K, V]{node: next.node})
+		return true
+	}
+}
+
+// Range calls yield for each entry until it returns false.
+// Like sync.Map.Range, it does not correspond to any consistent
+// snapshot, e.g. a key, which is deleted and stored again during
+// iteration, may be visited twice.
+func (m *HashMap[K, V]) Range(yield func(K, V) bool) {
+	for curr := m.head.next.Load().node; curr != nil; curr = curr.next.Load().node {
+		if curr.isDummy() {
+			continue
+		}
+		if v := curr.value.Load(); v != nil && !yield(curr.key, *v) {
+			return
+		}
+	}
+}
+
+func (m *HashMap[K, V]) store(key K, value *V, overwrite bool) *V {
+	h := m.hasher.Hash(key)
+	head, so := m.bucket(h), regularKey(h)
+
+	var n *hashNode[K, V]
+	for {
+		prev, link, found := m.find(head, so, key)
+		if found {
+			curr := link.node
+			old := curr.value.Load()
+			if old == nil {
+				continue
+			}
+			if !overwrite || curr.value.CompareAndSwap(old, value) {
+				return old
+			}
+			continue
+		}
+
+		if n == nil {
+			n = &hashNode[K, V]{soKey: so, key: key}
+			n.value.Store(value)
+		}
+
+		n.next.Store(&hashLink[K, V]{node: link.node})
+		if prev.next.CompareAndSwap(link, &hashLink[K, V]{node: n}) {
+			m.grow(m.count.Add(1))
+			return nil
+		}
+	}
+}
+
+// find searches for the node with given split-order key starting
+// from head and unlinks deleted nodes on its way. It returns
+// the predecessor and its next link, which points either to the
+// found node or to the position, where the node must be inserted.
+func (m *HashMap[K, V]) find(head *hashNode[K, V], so uint64, key K) (*hashNode[K, V], *hashLink[K, V], bool) {
+retry:
+	prev := head
+	link := prev.next.Load()
+	for {
+		curr := link.node
+		if curr == nil {
+			return prev, link, false
+		}
+
+		next := curr.next.Load()
+		if next.marked || curr.isDeleted() {
+			next = curr.mark()
+
+			unlinked := &hashLink[K, V]{node: next.node}
+			if !prev.next.CompareAndSwap(link, unlinked) {
+				goto retry
+			}
+
+			link = unlinked
+			continue
+		}
+
+		if curr.soKey > so {
+			return prev, link, false
+		}
+
+		if curr.soKey == so && (curr.isDummy() || curr.key == key) {
+			return prev, link, true
+		}
+
+		prev, link = curr, next
+	}
+}
+
+// bucket returns dummy node of the bucket for hash h.
+func (m *HashMap[K, V]) bucket(h uint64) *hashNode[K, V] {
+	t := m.table.Load()
+	return m.initBucket(t, h&uint64(len(t.slots)-1))
+}
+
+func (m *HashMap[K, V]) initBucket(t *hashTable[K, V], b uint64) *hashNode[K, V] {
+	if dummy := t.slots[b].Load(); dummy != nil {
+		return dummy
+	}
+
+	parent := m.initBucket(t, b&^(1<<(bits.Len64(b)-1)))
+
+	so := dummyKey(b)
+	for {
+		prev, link, found := m.find(parent, so, *new(K))
+		if found {
+			t.slots[b].CompareAndSwap(nil, link.node)
+			return link.node
+		}
+
+		dummy := &hashNode[K, V]{soKey: so}
+		dummy.next.Store(&hashLink[K, V]{node: link.node})
+		if prev.next.CompareAndSwap(link, &hashLink[K, V]{node: dummy}) {
+			t.slots[b].CompareAndSwap(nil, dummy)
+			return dummy
+		}
+	}
+}
+
+// grow doubles bucket table, if average bucket is too long.
+// Buckets of the new table are initialized lazily, so
+// concurrent operations on the old table are still valid.
+func (m *HashMap[K, V]) grow(count int64) {
+	t := m.table.Load()
+	if count <= int64(len(t.slots))*hashMapMaxLoad {
+		return
+	}
+
+	grown := &hashTable[K, V]{slots: make([]atomx.Pointer[hashNode[K, V]], 2*len(t.slots))}
+	for i := range t.slots {
+		grown.slots[i].Store(t.slots[i].Load())
+	}
+
+	m.table.CompareAndSwap(t, grown)
+}
+
+// regularKey makes split-order key for an entry. The highest
+// bit is set, so the key is odd and goes after its bucket.
+func regularKey(h uint64) uint64 {
+	return bits.Reverse64(h | 1<<63)
+}
+
+func dummyKey(b uint64) uint64 {
+	return bits.Reverse64(b)
+}
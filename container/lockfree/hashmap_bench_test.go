@@ -0,0 +1,79 @@
+package lockfree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+type concurrentMap interface {
+	Load(int) (int, bool)
+	Store(int, int)
+}
+
+type syncMap struct {
+	m sync.Map
+}
+
+func (s *syncMap) Load(k int) (int, bool) {
+	v, ok := s.m.Load(k)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+func (s *syncMap) Store(k, v int) {
+	s.m.Store(k, v)
+}
+
+func BenchmarkHashMap_CompareWithSyncMap(b *testing.B) {
+	const keys = 1 << 16
+
+	impls := []struct {
+		name string
+		init func() concurrentMap
+	}{
+		{"sync.Map", func() concurrentMap { return new(syncMap) }},
+		{"HashMap", func() concurrentMap { return NewHashMap[int, int]() }},
+	}
+
+	for _, writes := range []int{0, 10, 50, 100} {
+		for _, impl := range impls {
+			b.Run(fmt.Sprintf("%d%%_writes/%s", writes, impl.name), func(b *testing.B) {
+				m := impl.init()
+				for k := range keys {
+					m.Store(k, k)
+				}
+
+				b.ReportAllocs()
+				b.SetParallelism(4)
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					seed := uint64(uintptr(unsafe.Pointer(pb)))
+					rnd := rand.New(rand.NewPCG(seed, seed))
+					for pb.Next() {
+						k := rnd.IntN(keys)
+						if rnd.IntN(100) < writes {
+							m.Store(k, k)
+						} else {
+							m.Load(k)
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+func BenchmarkHashMap_Grow(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		m := NewHashMap[int, int]()
+		for k := range 10_000 {
+			m.Store(k, k)
+		}
+	}
+}
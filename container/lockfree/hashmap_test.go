@@ -0,0 +1,115 @@
+package lockfree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikmy/algo/testx/faulty"
+	"github.com/nikmy/algo/testx/synctest"
+)
+
+func TestHashMap_Sequential(t *testing.T) {
+	m := NewHashMap[int, string]()
+	et := make(map[int]string)
+
+	rnd := rand.New(rand.NewPCG(42, 42))
+	for range 100_000 {
+		k := rnd.IntN(10_000)
+		switch rnd.IntN(4) {
+		case 0, 1:
+			v := fmt.Sprint(rnd.Int())
+			m.Store(k, v)
+			et[k] = v
+		case 2:
+			_, ok := et[k]
+			require.Equal(t, ok, m.Delete(k))
+			delete(et, k)
+		case 3:
+			v, ok := m.LoadOrStore(k, "new")
+			old, loaded := et[k]
+			require.Equal(t, loaded, ok)
+			if loaded {
+				require.Equal(t, old, v)
+			} else {
+				et[k] = "new"
+			}
+		}
+	}
+
+	require.Equal(t, len(et), m.Len())
+	for k, v := range et {
+		got, ok := m.Load(k)
+		require.True(t, ok)
+		require.Equal(t, v, got)
+	}
+
+	visited := 0
+	for k, v := range m.Range {
+		require.Equal(t, et[k], v)
+		visited++
+	}
+	require.Equal(t, len(et), visited)
+}
+
+func TestHashMap_Safety(t *testing.T) {
+	m := NewHashMap[int, int]()
+
+	var i, j, l int
+
+	store := synctest.Operation{
+		Runner: func() { m.Store(i%512, i); i++ },
+		Actors: 1,
+	}
+
+	remove := synctest.Operation{
+		Runner: func() { m.Delete(j % 512); j += 3 },
+		Actors: 1,
+	}
+
+	load := synctest.Operation{
+		Runner: func() { m.Load(l % 512); l += 7 },
+		Actors: 1,
+	}
+
+	c := faulty.NewController(t, 42)
+	c.SetFaultProbability(0.2)
+
+	synctest.Stress(t, c, 100_000, store, remove, load)
+}
+
+func TestHashMap_ConcurrentGrow(t *testing.T) {
+	const (
+		actors   = 8
+		perActor = 10_000
+	)
+
+	m := NewHashMap[int, int]()
+
+	var wg sync.WaitGroup
+	for a := range actors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perActor {
+				m.Store(a*perActor+i, i)
+				if i%2 == 1 {
+					require.True(t, m.Delete(a*perActor+i-1))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, actors*perActor/2, m.Len())
+	for k := range actors * perActor {
+		v, ok := m.Load(k)
+		require.Equal(t, k%2 == 1, ok, "key %d", k)
+		if ok {
+			require.Equal(t, k%perActor, v)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package lockfree
 import (
 	"sync/atomic"
 
+	"github.com/nikmy/algo/container/lockfree/reclaim"
 	"github.com/nikmy/algo/syncx/atomx"
 )
 
@@ -13,18 +14,39 @@ type queueNode[T any] struct {
 
 func NewQueue[T any]() *Queue[T] {
 	q := &Queue[T]{sent: new(queueNode[T])}
-	q.sent.next.Store(q.sent)
-	q.head.Store(q.sent)
-	q.tail.Store(q.sent)
+	q.init(new(queueNode[T]))
+	return q
+}
 
+// NewPooledQueue returns queue, that recycles its
+// nodes instead of allocating them on every push.
+// Dequeued nodes are reused only after grace period
+// of the domain, so head and tail CAS are safe from ABA.
+func NewPooledQueue[T any](d *reclaim.Domain) *Queue[T] {
+	q := &Queue[T]{
+		sent:  new(queueNode[T]),
+		nodes: reclaim.NewPool[queueNode[T]](d),
+	}
+	q.init(q.nodes.Get())
 	return q
 }
 
-// Queue is lock-free queue implementation
+// Queue is lock-free Michael-Scott queue implementation.
+// Head always points to dummy node, which element has
+// already been consumed, and the last node is linked
+// to the sentinel.
 type Queue[T any] struct {
-	sent *queueNode[T]
-	head atomx.Pointer[queueNode[T]]
-	tail atomx.Pointer[queueNode[T]]
+	sent  *queueNode[T]
+	head  atomx.Pointer[queueNode[T]]
+	tail  atomx.Pointer[queueNode[T]]
+	nodes *reclaim.Pool[queueNode[T]]
+}
+
+func (q *Queue[T]) init(dummy *queueNode[T]) {
+	q.sent.next.Store(q.sent)
+	dummy.next.Store(q.sent)
+	q.head.Store(dummy)
+	q.tail.Store(dummy)
 }
 
 func (q *Queue[T]) TryProduce(x T) bool {
@@ -33,18 +55,61 @@ func (q *Queue[T]) TryProduce(x T) bool {
 }
 
 func (q *Queue[T]) TryConsume(x *T) bool {
-	if pop := q.PopFront(); pop != nil {
-		*x = *pop
-		return true
+	var g *reclaim.Guard
+	if q.nodes != nil {
+		g = q.nodes.Domain().Pin()
+		defer g.Unpin()
 	}
 
-	return false
+	for {
+		head := q.head.Load()
+		tail := q.tail.Load()
+
+		next := head.next.Load()
+		if next == q.sent {
+			return false
+		}
+
+		if head == tail {
+			// helping
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+
+		if !q.head.CompareAndSwap(head, next) {
+			continue
+		}
+
+		// next is new dummy, so its element
+		// is owned by current goroutine
+		*x = next.elem
+		next.elem = *new(T)
+
+		if g != nil {
+			q.nodes.Retire(g, head)
+		}
+
+		return true
+	}
 }
 
 func (q *Queue[T]) PushBack(x T) {
-	n := queueNode[T]{elem: x}
+	var n *queueNode[T]
+	if q.nodes != nil {
+		n = q.nodes.Get()
+	} else {
+		n = new(queueNode[T])
+	}
+
+	n.elem = x
 	n.next.Store(q.sent)
 
+	var g *reclaim.Guard
+	if q.nodes != nil {
+		g = q.nodes.Domain().Pin()
+		defer g.Unpin()
+	}
+
 	for {
 		tail := q.tail.Load()
 
@@ -55,24 +120,22 @@ func (q *Queue[T]) PushBack(x T) {
 			continue
 		}
 
-		if !tail.next.CompareAndSwap(next, &n) {
+		if !tail.next.CompareAndSwap(next, n) {
 			continue
 		}
 
-		q.tail.Store(&n)
+		q.tail.CompareAndSwap(tail, n)
 		break
 	}
 }
 
+// PopFront returns pointer to copy of the front
+// element, or nil, if the queue is empty.
 func (q *Queue[T]) PopFront() *T {
-	for {
-		head := q.head.Load()
-		if head == q.sent {
-			return nil
-		}
-
-		if q.head.CompareAndSwap(head, head.next.Load()) {
-			return &head.elem
-		}
+	pop := new(T)
+	if q.TryConsume(pop) {
+		return pop
 	}
+
+	return nil
 }
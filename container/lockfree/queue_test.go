@@ -2,8 +2,56 @@ package lockfree
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikmy/algo/container/lockfree/reclaim"
+	"github.com/nikmy/algo/testx/faulty"
+	"github.com/nikmy/algo/testx/synctest"
 )
 
 func BenchmarkQueue_CompareWithChannel(b *testing.B) {
 	compareParSeq(b, func(size uint32) bufferImpl { return NewQueue[int]() })
 }
+
+func TestQueue_FIFO(t *testing.T) {
+	for name, q := range map[string]*Queue[int]{
+		"default": NewQueue[int](),
+		"pooled":  NewPooledQueue[int](reclaim.NewDomain()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Nil(t, q.PopFront())
+
+			for i := range 1000 {
+				q.PushBack(i)
+			}
+
+			for i := range 1000 {
+				pop := q.PopFront()
+				require.NotNil(t, pop)
+				require.Equal(t, i, *pop)
+			}
+
+			require.Nil(t, q.PopFront())
+		})
+	}
+}
+
+func TestQueue_Safety(t *testing.T) {
+	q := NewPooledQueue[int](reclaim.NewDomain())
+
+	produce := synctest.Operation{
+		Runner: func() { q.PushBack(42) },
+		Actors: 2,
+	}
+
+	consume := synctest.Operation{
+		Runner: func() { q.TryConsume(new(int)) },
+		Actors: 2,
+	}
+
+	c := faulty.NewController(t, 42)
+	c.SetFaultProbability(0.2)
+
+	synctest.Stress(t, c, 100_000, produce, consume)
+}
@@ -0,0 +1,164 @@
+package reclaim
+
+import (
+	"unsafe"
+
+	"github.com/nikmy/algo/syncx"
+	"github.com/nikmy/algo/syncx/atomx"
+)
+
+// collectThreshold is the number of retired objects
+// in a single guard, after which it tries to advance
+// global epoch and free expired objects.
+const collectThreshold = 64
+
+func NewDomain() *Domain {
+	d := &Domain{}
+	d.epoch.Store(1)
+	d.guards.Store(new([]*Guard))
+	return d
+}
+
+// Domain is epoch based memory reclamation scheme.
+//
+// Every goroutine, that is going to dereference shared
+// nodes, must Pin the domain first and Unpin it, when
+// loaded pointers are not used anymore. Unlinked nodes
+// are passed to Guard.Retire and are released not earlier
+// than global epoch is advanced twice, which is possible
+// only when every pinned goroutine has observed the epoch.
+// Thus, no goroutine can hold a pointer to released node,
+// and recycling nodes does not lead to ABA problem.
+type Domain struct {
+	epoch atomx.Uint64
+	_     [64]byte
+
+	next   atomx.Uint32
+	lock   syncx.Mutex
+	guards atomx.Pointer[[]*Guard]
+}
+
+// Pin announces, that current goroutine is going
+// to read shared memory. The returned guard is owned
+// by the goroutine until Unpin is called.
+func (d *Domain) Pin() *Guard {
+	g := d.acquire()
+	for {
+		// epoch may be advanced before local one is published,
+		// then the guard would be pinned behind the global epoch
+		epoch := d.epoch.Load()
+		g.local.Store(epoch<<1 | pinnedBit)
+		if d.epoch.Load() == epoch {
+			return g
+		}
+	}
+}
+
+// Epoch returns current global epoch.
+func (d *Domain) Epoch() uint64 {
+	return d.epoch.Load()
+}
+
+func (d *Domain) acquire() *Guard {
+	// start from different guards, so all of them
+	// are reused and collect their retired objects
+	guards := *d.guards.Load()
+	start := int(d.next.Add(1))
+	for i := range guards {
+		g := guards[(start+i)%len(guards)]
+		if !g.owned.Load() && g.owned.CompareAndSwap(false, true) {
+			return g
+		}
+	}
+
+	g := &Guard{d: d}
+	g.owned.Store(true)
+
+	d.lock.Lock()
+	grown := append(*d.guards.Load(), g)
+	d.guards.Store(&grown)
+	d.lock.Unlock()
+
+	return g
+}
+
+// tryAdvance increments global epoch, if all pinned
+// guards have already observed the current one.
+func (d *Domain) tryAdvance() uint64 {
+	epoch := d.epoch.Load()
+	for _, g := range *d.guards.Load() {
+		local := g.local.Load()
+		if local&pinnedBit != 0 && local>>1 != epoch {
+			return epoch
+		}
+	}
+
+	if d.epoch.CompareAndSwap(epoch, epoch+1) {
+		return epoch + 1
+	}
+	return d.epoch.Load()
+}
+
+const pinnedBit = 1
+
+// Guard is a participant of reclamation domain.
+// It must not be shared between goroutines.
+type Guard struct {
+	local atomx.Uint64
+	owned atomx.Bool
+
+	d       *Domain
+	retired []retired
+}
+
+type retired struct {
+	epoch uint64
+	ptr   unsafe.Pointer
+	free  func(unsafe.Pointer)
+}
+
+// Unpin releases the guard. Pointers, that
+// were loaded after Pin, must not be used.
+func (g *Guard) Unpin() {
+	g.local.Store(0)
+	if len(g.retired) > 0 {
+		g.collect(g.d.Epoch())
+	}
+	g.owned.Store(false)
+}
+
+// Retire schedules free to be called when no goroutine
+// can reference the unlinked object anymore.
+func (g *Guard) Retire(free func()) {
+	g.retire(nil, func(unsafe.Pointer) { free() })
+}
+
+func (g *Guard) retire(ptr unsafe.Pointer, free func(unsafe.Pointer)) {
+	// stamp with global epoch, because readers of the
+	// object may be pinned later than the guard
+	g.retired = append(g.retired, retired{
+		epoch: g.d.epoch.Load(),
+		ptr:   ptr,
+		free:  free,
+	})
+
+	if len(g.retired) >= collectThreshold {
+		g.collect(g.d.tryAdvance())
+	}
+}
+
+// collect frees objects, that were retired
+// at least two epochs before the given one.
+func (g *Guard) collect(epoch uint64) {
+	kept := g.retired[:0]
+	for _, r := range g.retired {
+		if r.epoch+2 <= epoch {
+			r.free(r.ptr)
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	clear(g.retired[len(kept):])
+	g.retired = kept
+}
@@ -0,0 +1,120 @@
+package reclaim
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomain_GracePeriod(t *testing.T) {
+	d := NewDomain()
+
+	reader := d.Pin()
+
+	writer := d.Pin()
+	freed := false
+	writer.Retire(func() { freed = true })
+	writer.Unpin()
+
+	for range 2 * collectThreshold {
+		g := d.Pin()
+		g.Retire(func() {})
+		g.Unpin()
+	}
+
+	require.False(t, freed, "object is freed while reader is pinned")
+
+	reader.Unpin()
+
+	for range 2 * collectThreshold {
+		g := d.Pin()
+		g.Retire(func() {})
+		g.Unpin()
+	}
+
+	require.True(t, freed, "object is not freed after grace period")
+}
+
+func TestDomain_StaleGuard(t *testing.T) {
+	d := NewDomain()
+	d.epoch.Store(7)
+
+	// writer has been pinned, while epoch was advanced
+	writer := d.Pin()
+	writer.local.Store(5<<1 | pinnedBit)
+
+	reader := d.Pin()
+
+	freed := false
+	writer.Retire(func() { freed = true })
+	writer.Unpin()
+
+	for range 2 * collectThreshold {
+		g := d.Pin()
+		g.Retire(func() {})
+		g.Unpin()
+	}
+
+	require.False(t, freed, "object is freed while reader is pinned")
+
+	reader.Unpin()
+
+	for range 2 * collectThreshold {
+		g := d.Pin()
+		g.Retire(func() {})
+		g.Unpin()
+	}
+
+	require.True(t, freed, "object is not freed after grace period")
+}
+
+func TestDomain_GuardReuse(t *testing.T) {
+	d := NewDomain()
+
+	first := d.Pin()
+	second := d.Pin()
+	require.NotSame(t, first, second)
+
+	first.Unpin()
+	require.Same(t, first, d.Pin())
+	require.Len(t, *d.guards.Load(), 2)
+}
+
+func TestPool_Recycle(t *testing.T) {
+	type node struct {
+		value int
+		next  *node
+	}
+
+	d := NewDomain()
+	p := NewPool[node](d)
+
+	var (
+		wg    sync.WaitGroup
+		alive sync.Map
+	)
+
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 10_000 {
+				n := p.Get()
+				require.Zero(t, *n)
+
+				_, loaded := alive.LoadOrStore(n, struct{}{})
+				require.False(t, loaded, "node is reused before retire")
+
+				n.value = i
+
+				g := d.Pin()
+				alive.Delete(n)
+				p.Retire(g, n)
+				g.Unpin()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,50 @@
+package reclaim
+
+import (
+	"sync"
+	"unsafe"
+)
+
+func NewPool[T any](d *Domain) *Pool[T] {
+	p := &Pool[T]{d: d}
+	p.release = func(ptr unsafe.Pointer) {
+		x := (*T)(ptr)
+		*x = *new(T)
+		p.free.Put(x)
+	}
+	return p
+}
+
+// Pool recycles objects of lock-free containers.
+// Objects, that have been published, must be returned
+// through Retire, so they are reused only after grace
+// period of the domain.
+type Pool[T any] struct {
+	d       *Domain
+	free    sync.Pool
+	release func(unsafe.Pointer)
+}
+
+func (p *Pool[T]) Domain() *Domain {
+	return p.d
+}
+
+// Get returns zeroed object.
+func (p *Pool[T]) Get() *T {
+	if x, ok := p.free.Get().(*T); ok {
+		return x
+	}
+	return new(T)
+}
+
+// Put returns object, that has never been
+// visible to other goroutines, to the pool.
+func (p *Pool[T]) Put(x *T) {
+	p.release(unsafe.Pointer(x))
+}
+
+// Retire returns unlinked object to the pool,
+// when no goroutine can reference it anymore.
+func (p *Pool[T]) Retire(g *Guard, x *T) {
+	g.retire(unsafe.Pointer(x), p.release)
+}
@@ -0,0 +1,187 @@
+package lockfree
+
+import (
+	"math/bits"
+
+	"github.com/nikmy/algo/syncx/atomx"
+)
+
+func NewRing[T any](size uint32) *Ring[T] {
+	mask := bits.Len32(size) - 1
+	if bits.OnesCount32(size) > 1 {
+		mask++
+	}
+
+	size = max(uint32(2), 1<<uint32(mask))
+
+	r := &Ring[T]{
+		cells: make([]ringCell[T], size),
+		mask:  uint64(size - 1),
+	}
+	for i := range r.cells {
+		r.cells[i].seq.Store(uint64(i))
+	}
+
+	return r
+}
+
+// Ring is bounded queue for multiple producers
+// and multiple consumers, based on Dmitry Vyukov's
+// array queue. Every cell holds a sequence number,
+// that tells whether the cell is ready to be written
+// or read at the current lap, so producers and consumers
+// synchronize only through one CAS on their own index.
+// TryProduce and TryConsume are lock free and fail
+// immediately, if the ring is full or empty.
+type Ring[T any] struct {
+	_ [64]byte
+
+	tail atomx.Uint64
+	_    [64]byte
+
+	head atomx.Uint64
+	_    [64]byte
+
+	cells []ringCell[T]
+	mask  uint64
+}
+
+type ringCell[T any] struct {
+	seq  atomx.Uint64
+	elem T
+}
+
+// Cap returns maximum number of elements in the ring.
+func (r *Ring[T]) Cap() int {
+	return len(r.cells)
+}
+
+// Len returns approximate number of elements in the ring.
+func (r *Ring[T]) Len() int {
+	head, tail := r.head.Load(), r.tail.Load()
+	if tail < head {
+		return 0
+	}
+	return int(min(tail-head, uint64(len(r.cells))))
+}
+
+func (r *Ring[T]) TryProduce(x T) bool {
+	pos := r.tail.Load()
+	for {
+		cell := &r.cells[pos&r.mask]
+		diff := int64(cell.seq.Load() - pos)
+
+		switch {
+		case diff == 0:
+			if r.tail.CompareAndSwap(pos, pos+1) {
+				cell.elem = x
+				cell.seq.Store(pos + 1)
+				return true
+			}
+		case diff < 0:
+			return false
+		}
+
+		pos = r.tail.Load()
+	}
+}
+
+func (r *Ring[T]) TryConsume(x *T) bool {
+	pos := r.head.Load()
+	for {
+		cell := &r.cells[pos&r.mask]
+		diff := int64(cell.seq.Load() - (pos + 1))
+
+		switch {
+		case diff == 0:
+			if r.head.CompareAndSwap(pos, pos+1) {
+				*x = cell.elem
+				cell.elem = *new(T)
+				cell.seq.Store(pos + r.mask + 1)
+				return true
+			}
+		case diff < 0:
+			return false
+		}
+
+		pos = r.head.Load()
+	}
+}
+
+// TryProduceBatch reserves as many consecutive cells as
+// possible with a single CAS and fills them with prefix
+// of xs. Returns number of produced elements.
+func (r *Ring[T]) TryProduceBatch(xs []T) int {
+	if len(xs) == 0 {
+		return 0
+	}
+
+	for {
+		pos := r.tail.Load()
+		n := r.ready(pos, 0, len(xs))
+		if n == 0 {
+			if int64(r.cells[pos&r.mask].seq.Load()-pos) < 0 {
+				return 0
+			}
+			continue
+		}
+
+		if !r.tail.CompareAndSwap(pos, pos+uint64(n)) {
+			continue
+		}
+
+		for i := range n {
+			cell := &r.cells[(pos+uint64(i))&r.mask]
+			cell.elem = xs[i]
+			cell.seq.Store(pos + uint64(i) + 1)
+		}
+
+		return n
+	}
+}
+
+// TryConsumeBatch takes as many consecutive elements
+// as possible (up to len(buf)) with a single CAS.
+// Returns number of consumed elements.
+func (r *Ring[T]) TryConsumeBatch(buf []T) int {
+	if len(buf) == 0 {
+		return 0
+	}
+
+	for {
+		pos := r.head.Load()
+		n := r.ready(pos, 1, len(buf))
+		if n == 0 {
+			if int64(r.cells[pos&r.mask].seq.Load()-(pos+1)) < 0 {
+				return 0
+			}
+			continue
+		}
+
+		if !r.head.CompareAndSwap(pos, pos+uint64(n)) {
+			continue
+		}
+
+		for i := range n {
+			cell := &r.cells[(pos+uint64(i))&r.mask]
+			buf[i] = cell.elem
+			cell.elem = *new(T)
+			cell.seq.Store(pos + uint64(i) + r.mask + 1)
+		}
+
+		return n
+	}
+}
+
+// ready counts cells starting from pos, which sequence
+// number equals to their position plus shift.
+func (r *Ring[T]) ready(pos uint64, shift uint64, limit int) int {
+	limit = min(limit, len(r.cells))
+	for i := range limit {
+		p := pos + uint64(i)
+		if r.cells[p&r.mask].seq.Load() != p+shift {
+			return i
+		}
+	}
+	return limit
+}
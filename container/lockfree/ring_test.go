@@ -0,0 +1,135 @@
+package lockfree
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikmy/algo/testx/faulty"
+	"github.com/nikmy/algo/testx/synctest"
+)
+
+func TestRing_Safety(t *testing.T) {
+	ring := NewRing[int](64)
+
+	produce := synctest.Operation{
+		Runner: func() { ring.TryProduce(42) },
+		Actors: 2,
+	}
+
+	consume := synctest.Operation{
+		Runner: func() { ring.TryConsume(new(int)) },
+		Actors: 2,
+	}
+
+	produceBatch := synctest.Operation{
+		Runner: func() { ring.TryProduceBatch([]int{1, 2, 3}) },
+		Actors: 1,
+	}
+
+	consumeBatch := synctest.Operation{
+		Runner: func() { ring.TryConsumeBatch(make([]int, 3)) },
+		Actors: 1,
+	}
+
+	c := faulty.NewController(t, 42)
+	c.SetFaultProbability(0.2)
+
+	synctest.Stress(t, c, 100_000, produce, consume, produceBatch, consumeBatch)
+}
+
+func TestRing_Bounds(t *testing.T) {
+	ring := NewRing[int](3)
+	require.Equal(t, 4, ring.Cap())
+
+	for i := range 4 {
+		require.True(t, ring.TryProduce(i))
+	}
+	require.False(t, ring.TryProduce(4))
+	require.Equal(t, 4, ring.Len())
+
+	var x int
+	for i := range 4 {
+		require.True(t, ring.TryConsume(&x))
+		require.Equal(t, i, x)
+	}
+	require.False(t, ring.TryConsume(&x))
+
+	require.Equal(t, 3, ring.TryProduceBatch([]int{0, 1, 2}))
+	require.Equal(t, 1, ring.TryProduceBatch([]int{3, 4, 5}))
+
+	buf := make([]int, 8)
+	require.Equal(t, 4, ring.TryConsumeBatch(buf))
+	require.Equal(t, []int{0, 1, 2, 3}, buf[:4])
+	require.Equal(t, 0, ring.TryConsumeBatch(buf))
+}
+
+func TestRing_NoLoss(t *testing.T) {
+	const (
+		producers = 4
+		consumers = 4
+		perActor  = 50_000
+	)
+
+	ring := NewRing[int](128)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		consumed = make([]int, 0, producers*perActor)
+		done     = make(chan struct{})
+	)
+
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perActor {
+				for !ring.TryProduce(p*perActor + i) {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	var cwg sync.WaitGroup
+	for range consumers {
+		cwg.Add(1)
+		go func() {
+			defer cwg.Done()
+			local := make([]int, 0, perActor)
+			var x int
+			for {
+				if ring.TryConsume(&x) {
+					local = append(local, x)
+					continue
+				}
+				select {
+				case <-done:
+					for ring.TryConsume(&x) {
+						local = append(local, x)
+					}
+					mu.Lock()
+					consumed = append(consumed, local...)
+					mu.Unlock()
+					return
+				default:
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	cwg.Wait()
+
+	require.Len(t, consumed, producers*perActor)
+	seen := make([]bool, producers*perActor)
+	for _, x := range consumed {
+		require.False(t, seen[x], "duplicate %d", x)
+		seen[x] = true
+	}
+}
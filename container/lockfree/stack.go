@@ -1,6 +1,7 @@
 package lockfree
 
 import (
+	"github.com/nikmy/algo/container/lockfree/reclaim"
 	"github.com/nikmy/algo/syncx/atomx"
 )
 
@@ -9,8 +10,30 @@ type stackNode[T any] struct {
 	next *stackNode[T]
 }
 
+// NewPooledStack returns stack, that recycles its
+// nodes instead of allocating them on every push.
+// Popped nodes are reused only after grace period
+// of the domain, so the top CAS is safe from ABA.
+func NewPooledStack[T any](d *reclaim.Domain) *Stack[T] {
+	return &Stack[T]{nodes: reclaim.NewPool[stackNode[T]](d)}
+}
+
+// NewEliminationStack returns stack with elimination
+// array of the given width. Contended Push and Pop try
+// to exchange elements through the array instead of
+// retrying CAS on the top, which is a hot spot when
+// there are many cores.
+func NewEliminationStack[T any](width int) *Stack[T] {
+	return &Stack[T]{elim: make(eliminationArray[T], width)}
+}
+
+// Stack is lock-free Treiber stack. Zero
+// value is ready to use and does not recycle
+// nodes, relying on garbage collector.
 type Stack[T any] struct {
-	top atomx.Pointer[stackNode[T]]
+	top   atomx.Pointer[stackNode[T]]
+	nodes *reclaim.Pool[stackNode[T]]
+	elim  eliminationArray[T]
 }
 
 func (s *Stack[T]) Empty() bool {
@@ -18,6 +41,10 @@ func (s *Stack[T]) Empty() bool {
 }
 
 func (s *Stack[T]) TryPush(x T) bool {
+	if s.nodes != nil {
+		return s.tryPushPooled(x)
+	}
+
 	n := stackNode[T]{
 		elem: x,
 		next: s.top.Load(),
@@ -27,6 +54,123 @@ func (s *Stack[T]) TryPush(x T) bool {
 }
 
 func (s *Stack[T]) TryPop(x *T) bool {
+	if s.nodes != nil {
+		return s.tryPopPooled(x)
+	}
+
+	top := s.top.Load()
+	if top == nil {
+		return false
+	}
+
+	if !s.top.CompareAndSwap(top, top.next) {
+		return false
+	}
+
+	*x = top.elem
+	return true
+}
+
+// Push pushes x, retrying with backoff on contention.
+func (s *Stack[T]) Push(x T) {
+	var b backoff
+	for !s.TryPush(x) {
+		if s.elim.push(x) {
+			return
+		}
+		b.wait()
+	}
+}
+
+// Pop pops top element to x, retrying with backoff
+// on contention. Returns false, if the stack is empty.
+func (s *Stack[T]) Pop(x *T) bool {
+	var b backoff
+	for !s.Empty() {
+		if s.TryPop(x) || s.elim.pop(x) {
+			return true
+		}
+		b.wait()
+	}
+	return false
+}
+
+// PushAll atomically pushes all elements of xs,
+// so the last one is on the top of the stack.
+func (s *Stack[T]) PushAll(xs []T) {
+	if len(xs) == 0 {
+		return
+	}
+
+	var bottom, top *stackNode[T]
+	for _, x := range xs {
+		var n *stackNode[T]
+		if s.nodes != nil {
+			n = s.nodes.Get()
+		} else {
+			n = new(stackNode[T])
+		}
+
+		n.elem = x
+		n.next = top
+		top = n
+
+		if bottom == nil {
+			bottom = n
+		}
+	}
+
+	var b backoff
+	for {
+		bottom.next = s.top.Load()
+		if s.top.CompareAndSwap(bottom.next, top) {
+			return
+		}
+		b.wait()
+	}
+}
+
+// PopAll atomically takes all elements from the
+// stack and returns them in order of popping.
+func (s *Stack[T]) PopAll() []T {
+	if s.nodes == nil {
+		var popped []T
+		for n := s.top.Swap(nil); n != nil; n = n.next {
+			popped = append(popped, n.elem)
+		}
+		return popped
+	}
+
+	g := s.nodes.Domain().Pin()
+	defer g.Unpin()
+
+	var popped []T
+	for n := s.top.Swap(nil); n != nil; {
+		next := n.next
+		popped = append(popped, n.elem)
+		s.nodes.Retire(g, n)
+		n = next
+	}
+	return popped
+}
+
+func (s *Stack[T]) tryPushPooled(x T) bool {
+	n := s.nodes.Get()
+	n.elem = x
+	n.next = s.top.Load()
+
+	if s.top.CompareAndSwap(n.next, n) {
+		return true
+	}
+
+	s.nodes.Put(n)
+	return false
+}
+
+func (s *Stack[T]) tryPopPooled(x *T) bool {
+	g := s.nodes.Domain().Pin()
+	defer g.Unpin()
+
 	top := s.top.Load()
 	if top == nil {
 		return false
@@ -37,5 +181,6 @@ func (s *Stack[T]) TryPop(x *T) bool {
 	}
 
 	*x = top.elem
+	s.nodes.Retire(g, top)
 	return true
 }
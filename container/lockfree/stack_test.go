@@ -1,8 +1,13 @@
 package lockfree
 
 import (
+	"runtime"
+	"sync"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikmy/algo/container/lockfree/reclaim"
 	"github.com/nikmy/algo/syncx"
 	"github.com/nikmy/algo/testx/faulty"
 	"github.com/nikmy/algo/testx/synctest"
@@ -27,6 +32,132 @@ func TestStack_Safety(t *testing.T) {
 	synctest.Stress(t, c, 1_000_000, produce, consume)
 }
 
+func TestStack_PooledSafety(t *testing.T) {
+	stack := NewPooledStack[int](reclaim.NewDomain())
+
+	produce := synctest.Operation{
+		Runner: func() { stack.TryPush(42) },
+		Actors: 2,
+	}
+
+	consume := synctest.Operation{
+		Runner: func() { stack.TryPop(new(int)) },
+		Actors: 2,
+	}
+
+	c := faulty.NewController(t, 42)
+	c.SetFaultProbability(0.2)
+
+	synctest.Stress(t, c, 100_000, produce, consume)
+}
+
+func BenchmarkStack_Pooled(b *testing.B) {
+	for name, stack := range map[string]*Stack[int]{
+		"default": new(Stack[int]),
+		"pooled":  NewPooledStack[int](reclaim.NewDomain()),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				var x int
+				for pb.Next() {
+					for !stack.TryPush(x) {
+					}
+					stack.TryPop(&x)
+				}
+			})
+		})
+	}
+}
+
+func TestStack_Batch(t *testing.T) {
+	for name, stack := range map[string]*Stack[int]{
+		"default": new(Stack[int]),
+		"pooled":  NewPooledStack[int](reclaim.NewDomain()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Empty(t, stack.PopAll())
+
+			stack.PushAll([]int{1, 2, 3})
+			stack.Push(4)
+			stack.PushAll([]int{5, 6})
+
+			var top int
+			require.True(t, stack.Pop(&top))
+			require.Equal(t, 6, top)
+
+			require.Equal(t, []int{5, 4, 3, 2, 1}, stack.PopAll())
+			require.True(t, stack.Empty())
+			require.False(t, stack.Pop(&top))
+		})
+	}
+}
+
+func TestStack_Elimination(t *testing.T) {
+	const (
+		actors   = 4
+		perActor = 20_000
+	)
+
+	stack := NewEliminationStack[int](4)
+
+	var (
+		wg     sync.WaitGroup
+		popped [actors][]int
+	)
+
+	for a := range actors {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range perActor {
+				stack.Push(a*perActor + i)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			var x int
+			for len(popped[a]) < perActor {
+				if stack.Pop(&x) {
+					popped[a] = append(popped[a], x)
+				} else {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	require.True(t, stack.Empty())
+
+	seen := make([]bool, actors*perActor)
+	for _, p := range popped {
+		for _, x := range p {
+			require.False(t, seen[x], "duplicate %d", x)
+			seen[x] = true
+		}
+	}
+}
+
+func BenchmarkStack_Elimination(b *testing.B) {
+	for name, stack := range map[string]*Stack[int]{
+		"default":     new(Stack[int]),
+		"elimination": NewEliminationStack[int](runtime.GOMAXPROCS(0)),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.SetParallelism(4)
+			b.RunParallel(func(pb *testing.PB) {
+				var x int
+				for pb.Next() {
+					stack.Push(x)
+					stack.Pop(&x)
+				}
+			})
+		})
+	}
+}
+
 func TestStack_LIFO(t *testing.T) {
 	c := faulty.NewController(t, 42)
 
@@ -0,0 +1,139 @@
+package lockfree
+
+import (
+	"math/bits"
+
+	"github.com/nikmy/algo/syncx/atomx"
+)
+
+func NewWorkStealingDeque[T any](capacity int) *WorkStealingDeque[T] {
+	d := &WorkStealingDeque[T]{}
+	d.array.Store(newStealingArray[T](max(2, 1<<bits.Len(uint(capacity-1)))))
+	return d
+}
+
+// WorkStealingDeque is Chase-Lev deque for work-stealing
+// schedulers. The owner goroutine pushes and pops tasks
+// from the bottom in LIFO order without contention, while
+// other workers Steal from the top in FIFO order. Only
+// the owner may call PushBottom and PopBottom.
+//
+// The circular array grows when it is full. Stealers may
+// still read the old array, so it is never modified after
+// being replaced. Elements are stored by pointers, because
+// stealer may read a slot, which is being overwritten, and
+// then discard the value when its CAS fails.
+type WorkStealingDeque[T any] struct {
+	top atomx.Int64
+	_   [64]byte
+
+	bottom atomx.Int64
+	_      [64]byte
+
+	array atomx.Pointer[stealingArray[T]]
+}
+
+// Len returns approximate number of elements.
+func (d *WorkStealingDeque[T]) Len() int {
+	return int(max(0, d.bottom.Load()-d.top.Load()))
+}
+
+// PushBottom pushes x to the bottom. Owner only.
+func (d *WorkStealingDeque[T]) PushBottom(x T) {
+	b := d.bottom.Load()
+	t := d.top.Load()
+	a := d.array.Load()
+
+	if b-t >= a.size() {
+		a = a.grow(t, b)
+		d.array.Store(a)
+	}
+
+	a.put(b, &x)
+	d.bottom.Store(b + 1)
+}
+
+// PopBottom pops the last pushed element. Owner only.
+func (d *WorkStealingDeque[T]) PopBottom() (T, bool) {
+	b := d.bottom.Load() - 1
+	a := d.array.Load()
+	d.bottom.Store(b)
+
+	t := d.top.Load()
+	if t > b {
+		d.bottom.Store(b + 1)
+		return *new(T), false
+	}
+
+	x := a.get(b)
+	if t < b {
+		a.put(b, nil)
+		return *x, true
+	}
+
+	// the last element, race with stealers
+	won := d.top.CompareAndSwap(t, t+1)
+	d.bottom.Store(b + 1)
+	if !won {
+		return *new(T), false
+	}
+
+	a.put(b, nil)
+	return *x, true
+}
+
+// Steal takes the oldest element. It is safe to call
+// from any goroutine. Returns false, if the deque is
+// empty or another goroutine took the element first.
+func (d *WorkStealingDeque[T]) Steal() (T, bool) {
+	t := d.top.Load()
+	b := d.bottom.Load()
+	if t >= b {
+		return *new(T), false
+	}
+
+	a := d.array.Load()
+	x := a.get(t)
+	if x == nil || !d.top.CompareAndSwap(t, t+1) {
+		return *new(T), false
+	}
+
+	a.cas(t, x, nil)
+	return *x, true
+}
+
+func newStealingArray[T any](size int) *stealingArray[T] {
+	return &stealingArray[T]{
+		data: make([]atomx.Pointer[T], size),
+		mask: int64(size - 1),
+	}
+}
+
+type stealingArray[T any] struct {
+	data []atomx.Pointer[T]
+	mask int64
+}
+
+func (a *stealingArray[T]) size() int64 {
+	return int64(len(a.data))
+}
+
+func (a *stealingArray[T]) get(i int64) *T {
+	return a.data[i&a.mask].Load()
+}
+
+func (a *stealingArray[T]) put(i int64, x *T) {
+	a.data[i&a.mask].Store(x)
+}
+
+func (a *stealingArray[T]) cas(i int64, old, new *T) {
+	a.data[i&a.mask].CompareAndSwap(old, new)
+}
+
+func (a *stealingArray[T]) grow(top, bottom int64) *stealingArray[T] {
+	grown := newStealingArray[T](2 * len(a.data))
+	for i := top; i < bottom; i++ {
+		grown.put(i, a.get(i))
+	}
+	return grown
+}
@@ -0,0 +1,131 @@
+package lockfree
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikmy/algo/testx/faulty"
+	"github.com/nikmy/algo/testx/synctest"
+)
+
+func TestWorkStealingDeque_Order(t *testing.T) {
+	d := NewWorkStealingDeque[int](1)
+
+	for i := range 100 {
+		d.PushBottom(i)
+	}
+	require.Equal(t, 100, d.Len())
+
+	x, ok := d.Steal()
+	require.True(t, ok)
+	require.Equal(t, 0, x)
+
+	x, ok = d.PopBottom()
+	require.True(t, ok)
+	require.Equal(t, 99, x)
+
+	for i := 98; i >= 1; i-- {
+		x, ok = d.PopBottom()
+		require.True(t, ok)
+		require.Equal(t, i, x)
+	}
+
+	_, ok = d.PopBottom()
+	require.False(t, ok)
+	_, ok = d.Steal()
+	require.False(t, ok)
+}
+
+func TestWorkStealingDeque_Safety(t *testing.T) {
+	d := NewWorkStealingDeque[int](4)
+
+	var i int
+	owner := synctest.Operation{
+		Runner: func() {
+			if i%3 == 2 {
+				d.PopBottom()
+			} else {
+				d.PushBottom(i)
+			}
+			i++
+		},
+		Actors: 1,
+	}
+
+	steal := synctest.Operation{
+		Runner: func() { d.Steal() },
+		Actors: 3,
+	}
+
+	c := faulty.NewController(t, 42)
+	c.SetFaultProbability(0.2)
+
+	synctest.Stress(t, c, 100_000, owner, steal)
+}
+
+func TestWorkStealingDeque_ExactlyOnce(t *testing.T) {
+	const (
+		tasks    = 200_000
+		stealers = 3
+	)
+
+	d := NewWorkStealingDeque[int](8)
+
+	var (
+		wg    sync.WaitGroup
+		taken [stealers + 1][]int
+		done  = make(chan struct{})
+	)
+
+	for s := range stealers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if x, ok := d.Steal(); ok {
+					taken[s] = append(taken[s], x)
+					continue
+				}
+				select {
+				case <-done:
+					return
+				default:
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	for i := range tasks {
+		d.PushBottom(i)
+		if i%2 == 1 {
+			if x, ok := d.PopBottom(); ok {
+				taken[stealers] = append(taken[stealers], x)
+			}
+		}
+	}
+	for {
+		x, ok := d.PopBottom()
+		if !ok {
+			break
+		}
+		taken[stealers] = append(taken[stealers], x)
+	}
+
+	close(done)
+	wg.Wait()
+
+	seen := make([]bool, tasks)
+	total := 0
+	for _, xs := range taken {
+		for _, x := range xs {
+			require.False(t, seen[x], "task %d is taken twice", x)
+			seen[x] = true
+			total++
+		}
+	}
+	require.Equal(t, tasks, total)
+}
@@ -0,0 +1,260 @@
+package static
+
+import (
+	"encoding/binary"
+	"reflect"
+	"unsafe"
+)
+
+// Serialized map is a sequence of little-endian 64-bit words,
+// so the loader may use the words of the hash function and
+// string offsets in place:
+//
+//	header  "SMAP", version, kinds of K and V, sizes of K and V
+//	seed    seed of keys hash
+//	mphf    seed, number of keys, table size, pilots, remap
+//	keys    column of keys
+//	values  column of values
+//
+// Packed array is its bit width, number of words and words.
+// Column of numbers or booleans is their memory representation
+// padded to the word boundary. Size of int, uint and uintptr
+// depends on platform, so sizes are stored in the header as
+// two 4-bit numbers, and they are zero for strings. Column of strings is N+1 offsets
+// into the following bytes, which are padded as well.
+
+const (
+	encodingMagic   = "SMAP"
+	encodingVersion = 2
+)
+
+// MarshalBinary encodes the map, so it can be restored
+// by Unmarshal or Load without rebuilding. Keys and values
+// must be strings, numbers or booleans.
+func (h *hmap[K, V]) MarshalBinary() ([]byte, error) {
+	if !h.portable || !portable[V]() || !littleEndian() {
+		return nil, ErrNotPortable
+	}
+
+	buf := make([]byte, 0, 64+h.index.bits()/8+len(h.data)*int(unsafe.Sizeof(h.data[0])))
+	buf = append(buf, encodingMagic...)
+	buf = append(buf, encodingVersion, kindOf[K](), kindOf[V](), sizeOf[K]()|sizeOf[V]()<<4)
+	buf = binary.LittleEndian.AppendUint64(buf, h.seed)
+	buf = binary.LittleEndian.AppendUint64(buf, h.index.seed)
+	buf = binary.LittleEndian.AppendUint64(buf, h.index.n)
+	buf = binary.LittleEndian.AppendUint64(buf, h.index.size)
+	buf = appendPacked(buf, h.index.pilots)
+	buf = appendPacked(buf, h.index.remap)
+	buf = appendColumn(buf, len(h.data), func(i int) *K { return &h.data[i].Key })
+	buf = appendColumn(buf, len(h.data), func(i int) *V { return &h.data[i].Val })
+	return buf, nil
+}
+
+// Unmarshal decodes the map encoded by MarshalBinary.
+// It copies everything, so data may be reused then.
+func Unmarshal[K comparable, V any](data []byte) (Map[K, V], error) {
+	return decode[K, V](data, false)
+}
+
+// Load decodes the map encoded by MarshalBinary without
+// copying of the hash function and strings, which refer
+// to data then, e.g. to mmap-ed file or embedded bytes.
+// Words are used in place, only if data is 8-byte aligned.
+// Data must not be modified, while the map is in use.
+func Load[K comparable, V any](data []byte) (Map[K, V], error) {
+	return decode[K, V](data, true)
+}
+
+func decode[K comparable, V any](data []byte, inplace bool) (Map[K, V], error) {
+	if !portable[K]() || !portable[V]() || !littleEndian() {
+		return nil, ErrNotPortable
+	}
+
+	r := reader{data: data, inplace: inplace}
+	header := r.bytes(8)
+	if r.err != nil || string(header[:4]) != encodingMagic || header[4] != encodingVersion ||
+		header[5] != kindOf[K]() || header[6] != kindOf[V]() {
+		return nil, ErrCorrupted
+	}
+	if header[7] != sizeOf[K]()|sizeOf[V]()<<4 {
+		// e.g. int of another platform
+		return nil, ErrNotPortable
+	}
+
+	h := &hmap[K, V]{seed: r.uint64()}
+	h.hash, h.portable = keyHasher[K](h.seed)
+	h.index.seed = r.uint64()
+	h.index.n = r.uint64()
+	h.index.size = r.uint64()
+	h.index.pilots = r.packed()
+	h.index.remap = r.packed()
+	if r.err != nil || h.index.n == 0 || h.index.n > uint64(len(data)) || h.index.size < h.index.n {
+		return nil, ErrCorrupted
+	}
+
+	h.data = make([]KV[K, V], h.index.n)
+	readColumn(&r, len(h.data), func(i int) *K { return &h.data[i].Key })
+	readColumn(&r, len(h.data), func(i int) *V { return &h.data[i].Val })
+	if r.err != nil || !h.index.valid() {
+		return nil, ErrCorrupted
+	}
+	return h, nil
+}
+
+func portable[T any]() bool {
+	t := reflect.TypeFor[T]()
+	return t.Kind() == reflect.String || fixedSize(t)
+}
+
+func kindOf[T any]() uint8 {
+	return uint8(reflect.TypeFor[T]().Kind())
+}
+
+// sizeOf returns size of memory representation of T in
+// column, which is at most 8 bytes, or zero for strings.
+func sizeOf[T any]() uint8 {
+	t := reflect.TypeFor[T]()
+	if t.Kind() == reflect.String {
+		return 0
+	}
+	return uint8(t.Size())
+}
+
+func littleEndian() bool {
+	return binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+}
+
+func appendPacked(buf []byte, p packed) []byte {
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(p.width))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(p.words)))
+	for _, w := range p.words {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf
+}
+
+func appendColumn[T any](buf []byte, n int, at func(int) *T) []byte {
+	if reflect.TypeFor[T]().Kind() != reflect.String {
+		for i := range n {
+			buf = append(buf, unsafe.Slice((*byte)(unsafe.Pointer(at(i))), unsafe.Sizeof(*at(i)))...)
+		}
+		return pad(buf)
+	}
+
+	offset := uint64(0)
+	buf = binary.LittleEndian.AppendUint64(buf, offset)
+	for i := range n {
+		offset += uint64(len(*(*string)(unsafe.Pointer(at(i)))))
+		buf = binary.LittleEndian.AppendUint64(buf, offset)
+	}
+	for i := range n {
+		buf = append(buf, *(*string)(unsafe.Pointer(at(i)))...)
+	}
+	return pad(buf)
+}
+
+func readColumn[T any](r *reader, n int, at func(int) *T) {
+	if reflect.TypeFor[T]().Kind() != reflect.String {
+		size := int(reflect.TypeFor[T]().Size())
+		b := r.bytes(n * size)
+		for i := 0; r.err == nil && i < n; i++ {
+			copy(unsafe.Slice((*byte)(unsafe.Pointer(at(i))), size), b[i*size:])
+		}
+		r.align()
+		return
+	}
+
+	offsets := r.words(n + 1)
+	if r.err != nil || offsets[0] != 0 || offsets[n] > uint64(len(r.data)) {
+		r.err = ErrCorrupted
+		return
+	}
+
+	b := r.bytes(int(offsets[n]))
+	for i := 0; r.err == nil && i < n; i++ {
+		lo, hi := offsets[i], offsets[i+1]
+		if lo > hi || hi > offsets[n] {
+			r.err = ErrCorrupted
+			return
+		}
+
+		s := (*string)(unsafe.Pointer(at(i)))
+		if r.inplace && hi > lo {
+			*s = unsafe.String(&b[lo], hi-lo)
+		} else {
+			*s = string(b[lo:hi])
+		}
+	}
+	r.align()
+}
+
+func pad(buf []byte) []byte {
+	for len(buf)%8 != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// reader decodes the words. It remembers the first
+// error, so it is checked once after a few reads.
+type reader struct {
+	data    []byte
+	off     int
+	inplace bool
+	err     error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.data)-r.off {
+		r.err = ErrCorrupted
+		return nil
+	}
+
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *reader) align() {
+	r.bytes((8 - r.off%8) % 8)
+}
+
+func (r *reader) uint64() uint64 {
+	b := r.bytes(8)
+	if r.err != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (r *reader) words(n int) []uint64 {
+	if n < 0 || n > (len(r.data)-r.off)/8 {
+		r.err = ErrCorrupted
+		return nil
+	}
+
+	b := r.bytes(8 * n)
+	if r.err != nil || n == 0 {
+		return nil
+	}
+
+	if r.inplace && uintptr(unsafe.Pointer(&b[0]))%8 == 0 {
+		return unsafe.Slice((*uint64)(unsafe.Pointer(&b[0])), n)
+	}
+
+	words := make([]uint64, n)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(b[8*i:])
+	}
+	return words
+}
+
+func (r *reader) packed() packed {
+	width := r.uint64()
+	n := r.uint64()
+	if r.err != nil || width > 64 || n > uint64(len(r.data)) {
+		r.err = ErrCorrupted
+		return packed{}
+	}
+	return packed{width: uint8(width), words: r.words(int(n))}
+}
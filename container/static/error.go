@@ -3,3 +3,11 @@ package static
 type Error string
 
 func (e Error) Error() string { return string(e) }
+
+const (
+	ErrNoEntries    = Error("static: no entries")
+	ErrDuplicateKey = Error("static: duplicate key")
+	ErrBuildFailed  = Error("static: perfect hash build attempts exceeded")
+	ErrNotPortable  = Error("static: map with such keys or values can't be serialized")
+	ErrCorrupted    = Error("static: corrupted or incompatible data")
+)
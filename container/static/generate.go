@@ -0,0 +1,54 @@
+package static
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// GenerateSource writes Go source file of package pkg, which
+// declares variable of given name with the prebuilt map m, so
+// it isn't built at process start. It is meant to be called
+// by a small program under go:generate directive, e.g.
+//
+//	//go:generate go run ./gen -o table_gen.go
+//
+// Keys and values must be of predeclared types.
+func GenerateSource[K comparable, V any](w io.Writer, pkg, name string, m Map[K, V]) error {
+	k, v := reflect.TypeFor[K](), reflect.TypeFor[V]()
+	if k.PkgPath() != "" || v.PkgPath() != "" {
+		return ErrNotPortable
+	}
+
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	src := fmt.Sprintf(`// Code generated by static.GenerateSource. DO NOT EDIT.
+
+package %[1]s
+
+import "github.com/nikmy/algo/container/static"
+
+var %[2]s = func() static.Map[%[3]s, %[4]s] {
+	m, err := static.Load[%[3]s, %[4]s](%[2]sData)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}()
+
+var %[2]sData = []byte(%[5]s)
+`, pkg, name, k, v, strconv.Quote(string(data)))
+
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(formatted)
+	return err
+}
@@ -0,0 +1,70 @@
+package static
+
+import (
+	"hash/maphash"
+	"math"
+	"reflect"
+	"unsafe"
+
+	"github.com/nikmy/algo/container/hashmap"
+)
+
+// keyHasher returns hash of keys with given seed. It is
+// deterministic for strings, numbers and booleans, so maps
+// with such keys may be serialized. Other keys are hashed
+// by maphash, which is randomized per process, and then
+// keyHasher reports false.
+func keyHasher[K comparable](seed uint64) (func(K) uint64, bool) {
+	t := reflect.TypeFor[K]()
+	switch t.Kind() {
+	case reflect.String:
+		hash := hashmap.StringHash[string](seed)
+		return func(key K) uint64 {
+			return hash(*(*string)(unsafe.Pointer(&key)))
+		}, true
+	case reflect.Float32:
+		hash := hashmap.IntHash[uint32](seed)
+		return func(key K) uint64 {
+			// +0 and -0 are equal keys
+			return hash(math.Float32bits(*(*float32)(unsafe.Pointer(&key)) + 0))
+		}, true
+	case reflect.Float64:
+		hash := hashmap.IntHash[uint64](seed)
+		return func(key K) uint64 {
+			return hash(math.Float64bits(*(*float64)(unsafe.Pointer(&key)) + 0))
+		}, true
+	}
+
+	if fixedSize(t) {
+		hash := hashmap.IntHash[uint64](seed)
+		switch t.Size() {
+		case 1:
+			return func(key K) uint64 { return hash(uint64(*(*uint8)(unsafe.Pointer(&key)))) }, true
+		case 2:
+			return func(key K) uint64 { return hash(uint64(*(*uint16)(unsafe.Pointer(&key)))) }, true
+		case 4:
+			return func(key K) uint64 { return hash(uint64(*(*uint32)(unsafe.Pointer(&key)))) }, true
+		case 8:
+			return func(key K) uint64 { return hash(*(*uint64)(unsafe.Pointer(&key))) }, true
+		}
+	}
+
+	s := maphash.MakeSeed()
+	return func(key K) uint64 {
+		return maphash.Comparable(s, key)
+	}, false
+}
+
+// fixedSize reports whether values of type t are
+// serialized as their memory representation.
+func fixedSize(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
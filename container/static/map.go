@@ -1,9 +1,8 @@
 package static
 
 import (
-	"hash/maphash"
+	"fmt"
 	"iter"
-	"math/rand/v2"
 )
 
 // UNSTABLE
@@ -14,58 +13,101 @@ type KV[K comparable, V any] struct {
 	Val V
 }
 
+// NewMap builds the map. It returns ErrNoEntries for empty
+// input and ErrDuplicateKey, if some key is given twice. Build
+// makes a bounded number of attempts, and then falls back to
+// sparser table, which is slightly larger, but easier to build.
+//
 // UNSTABLE
-func NewMap[K comparable, V any](entries ...KV[K, V]) Map[K, V] {
-	size := 4 * len(entries)
-	seed := maphash.MakeSeed()
-	for {
-		a, b := rand.Uint64(), rand.Uint64N(uint64(size))
-		hash := func(k K) int {
-			p := maphash.Comparable(seed, k)
-			h := int((a*p + b) % (1<<31 - 1))
-			h = h % size
-			if h < 0 {
-				h += size
-			}
-			return h
+func NewMap[K comparable, V any](entries ...KV[K, V]) (Map[K, V], error) {
+	if len(entries) == 0 {
+		return nil, ErrNoEntries
+	}
+
+	hashes := make([]uint64, len(entries))
+	for attempt := range 2 * mphfMaxSeeds {
+		seed := newSeed()
+		hash, portable := keyHasher[K](seed)
+		for i, kv := range entries {
+			hashes[i] = hash(kv.Key)
 		}
 
-		if tryHash(hash, entries) {
-			data := make([]KV[K, V], size)
-			for _, kv := range entries {
-				data[hash(kv.Key)] = kv
-			}
-			return &hmap[K, V]{
-				hash: hash,
-				data: data,
+		loadFactor := mphfLoadFactor
+		if attempt >= mphfMaxSeeds {
+			loadFactor = mphfFallbackLoadFactor
+		}
+
+		index, err := newMPHF(hashes, newSeed(), loadFactor)
+		if err == errHashCollision {
+			if key, found := duplicate(entries); found {
+				return nil, fmt.Errorf("%w: %v", ErrDuplicateKey, key)
 			}
 		}
+		if err != nil {
+			continue
+		}
+
+		data := make([]KV[K, V], len(entries))
+		for i, kv := range entries {
+			data[index.index(hashes[i])] = kv
+		}
+		return &hmap[K, V]{
+			seed:     seed,
+			hash:     hash,
+			portable: portable,
+			index:    index,
+			data:     data,
+		}, nil
 	}
+
+	return nil, ErrBuildFailed
+}
+
+// MustNewMap is like NewMap, but panics on error.
+//
+// UNSTABLE
+func MustNewMap[K comparable, V any](entries ...KV[K, V]) Map[K, V] {
+	m, err := NewMap(entries...)
+	if err != nil {
+		panic(err)
+	}
+	return m
 }
 
-func tryHash[K comparable, V any](hash func(K) int, entries []KV[K, V]) bool {
-	taken := map[int]struct{}{}
+// duplicate finds some key, which is given twice.
+func duplicate[K comparable, V any](entries []KV[K, V]) (K, bool) {
+	seen := make(map[K]struct{}, len(entries))
 	for _, kv := range entries {
-		i := hash(kv.Key)
-		if _, ok := taken[i]; ok {
-			return false
+		if _, ok := seen[kv.Key]; ok {
+			return kv.Key, true
 		}
-		taken[i] = struct{}{}
+		seen[kv.Key] = struct{}{}
 	}
-	return true
+	return *new(K), false
 }
 
+// hmap stores entries in the slots given by minimal perfect
+// hash of keys, so it takes no memory for empty slots. Hash
+// of portable map is the same in every process, so the map
+// may be serialized.
 type hmap[K comparable, V any] struct {
-	hash func(K) int
-	data []KV[K, V]
+	seed     uint64
+	hash     func(K) uint64
+	portable bool
+	index    mphf
+	data     []KV[K, V]
+}
+
+func (h *hmap[K, V]) slot(key K) *KV[K, V] {
+	return &h.data[h.index.index(h.hash(key))]
 }
 
 func (h *hmap[K, V]) HasKey(key K) bool {
-	return h.data[h.hash(key)].Key == key
+	return h.slot(key).Key == key
 }
 
 func (h *hmap[K, V]) Lookup(key K) (V, bool) {
-	kv := h.data[h.hash(key)]
+	kv := h.slot(key)
 	if kv.Key == key {
 		return kv.Val, true
 	}
@@ -86,3 +128,9 @@ func (h *hmap[K, V]) Entries() iter.Seq2[K, V] {
 		}
 	}
 }
+
+// BitsPerKey returns number of bits per key, which the
+// hash function takes in addition to the entries.
+func (h *hmap[K, V]) BitsPerKey() float64 {
+	return float64(h.index.bits()) / float64(len(h.data))
+}
@@ -1,7 +1,12 @@
 package static
 
 import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strconv"
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/require"
 )
@@ -13,9 +18,205 @@ func TestMap(t *testing.T) {
 		for i := range cap(squares) {
 			squares = append(squares, kv{i, i * i})
 		}
-		m := NewMap(squares...)
+		m := MustNewMap(squares...)
 		for k, v := range m.Entries() {
 			require.Equal(t, k*k, v)
 		}
 	})
 }
+
+func TestMap_Large(t *testing.T) {
+	type kv = KV[string, int]
+	entries := make([]kv, 0, 1_000_000)
+	for i := range cap(entries) {
+		entries = append(entries, kv{strconv.Itoa(i), i})
+	}
+
+	m := MustNewMap(entries...)
+	for _, e := range entries {
+		v, ok := m.Lookup(e.Key)
+		require.True(t, ok)
+		require.Equal(t, e.Val, v)
+	}
+	for i := range 1000 {
+		require.False(t, m.HasKey(strconv.Itoa(-i-1)))
+	}
+
+	n := 0
+	for range m.Entries() {
+		n++
+	}
+	require.Equal(t, len(entries), n)
+	require.Less(t, m.BitsPerKey(), 6.0)
+}
+
+func TestPacked(t *testing.T) {
+	xs := []uint64{0, 1, 1<<13 - 1, 42, 1 << 12, 7}
+	p := newPacked(xs)
+	for i, x := range xs {
+		require.Equal(t, x, p.get(uint64(i)))
+	}
+}
+
+func TestMap_Marshal(t *testing.T) {
+	type kv = KV[string, int64]
+	entries := make([]kv, 0, 10_000)
+	for i := range cap(entries) {
+		entries = append(entries, kv{"key" + strconv.Itoa(i), int64(i) * 3})
+	}
+
+	data, err := MustNewMap(entries...).MarshalBinary()
+	require.NoError(t, err)
+
+	for name, decode := range map[string]func([]byte) (Map[string, int64], error){
+		"unmarshal": Unmarshal[string, int64],
+		"load":      Load[string, int64],
+	} {
+		t.Run(name, func(t *testing.T) {
+			m, err := decode(data)
+			require.NoError(t, err)
+			for _, e := range entries {
+				v, ok := m.Lookup(e.Key)
+				require.True(t, ok)
+				require.Equal(t, e.Val, v)
+			}
+			require.False(t, m.HasKey("key-1"))
+
+			again, err := m.MarshalBinary()
+			require.NoError(t, err)
+			require.Equal(t, data, again)
+		})
+	}
+
+	_, err = Unmarshal[int, int64](data)
+	require.ErrorIs(t, err, ErrCorrupted)
+	_, err = Unmarshal[string, int64](data[:len(data)/2])
+	require.ErrorIs(t, err, ErrCorrupted)
+}
+
+func TestMap_MarshalFloats(t *testing.T) {
+	m := MustNewMap(KV[float64, bool]{0, true}, KV[float64, bool]{1.5, false})
+	require.True(t, m.HasKey(math.Copysign(0, -1)))
+
+	data, err := m.MarshalBinary()
+	require.NoError(t, err)
+	loaded, err := Load[float64, bool](data)
+	require.NoError(t, err)
+	require.True(t, loaded.Get(0))
+	require.True(t, loaded.HasKey(1.5))
+}
+
+func TestMap_NotPortable(t *testing.T) {
+	type key struct{ a, b int }
+	_, err := MustNewMap(KV[key, int]{key{1, 2}, 3}).MarshalBinary()
+	require.ErrorIs(t, err, ErrNotPortable)
+}
+
+func TestGenerateSource(t *testing.T) {
+	m := MustNewMap(KV[string, string]{"a", "b"}, KV[string, string]{"c", "d"})
+
+	var buf bytes.Buffer
+	require.NoError(t, GenerateSource(&buf, "tables", "letters", m))
+	require.Contains(t, buf.String(), "package tables")
+	require.Contains(t, buf.String(), "static.Load[string, string](lettersData)")
+}
+
+func TestMap_Errors(t *testing.T) {
+	_, err := NewMap[int, int]()
+	require.ErrorIs(t, err, ErrNoEntries)
+
+	_, err = NewMap(KV[int, int]{1, 1}, KV[int, int]{2, 2}, KV[int, int]{1, 3})
+	require.ErrorIs(t, err, ErrDuplicateKey)
+	require.ErrorContains(t, err, "duplicate key: 1")
+
+	require.Panics(t, func() { MustNewMap(KV[string, int]{"a", 1}, KV[string, int]{"a", 1}) })
+}
+
+func TestMap_Fallback(t *testing.T) {
+	hashes := make([]uint64, 100_000)
+	for i := range hashes {
+		hashes[i] = mix(uint64(i))
+	}
+
+	f, err := newMPHF(hashes, 42, mphfFallbackLoadFactor)
+	require.NoError(t, err)
+
+	taken := make(map[uint64]struct{}, len(hashes))
+	for _, h := range hashes {
+		i := f.index(h)
+		require.Less(t, i, uint64(len(hashes)))
+		taken[i] = struct{}{}
+	}
+	require.Len(t, taken, len(hashes))
+}
+
+func TestMap_Corrupted(t *testing.T) {
+	entries := make([]KV[int64, int64], 1000)
+	for i := range entries {
+		entries[i] = KV[int64, int64]{int64(i), int64(i)}
+	}
+
+	data, err := MustNewMap(entries...).MarshalBinary()
+	require.NoError(t, err)
+
+	// header, seeds, n and then size
+	const sizeOffset = 32
+
+	huge := bytes.Clone(data)
+	binary.LittleEndian.PutUint64(huge[sizeOffset:], 1<<63)
+	_, err = Load[int64, int64](huge)
+	require.ErrorIs(t, err, ErrCorrupted)
+
+	// pilots and remap are packed as width,
+	// number of words and then the words
+	pilots := sizeOffset + 8
+	remap := pilots + 16 + 8*int(binary.LittleEndian.Uint64(data[pilots+8:]))
+
+	empty := append(bytes.Clone(data[:remap]), make([]byte, 16)...)
+	empty = append(empty, data[remap+16+8*int(binary.LittleEndian.Uint64(data[remap+8:])):]...)
+	_, err = Unmarshal[int64, int64](empty)
+	require.ErrorIs(t, err, ErrCorrupted)
+}
+
+func TestMap_PlatformSize(t *testing.T) {
+	entries := make([]KV[int, int], 100)
+	for i := range entries {
+		entries[i] = KV[int, int]{i, i}
+	}
+
+	data, err := MustNewMap(entries...).MarshalBinary()
+	require.NoError(t, err)
+	_, err = Load[int, int](data)
+	require.NoError(t, err)
+
+	// int of native size and int of another
+	// platform, i.e. 4 bytes instead of 8 or vice versa
+	size := byte(unsafe.Sizeof(0))
+	data[7] = size | (12-size)<<4
+	_, err = Load[int, int](data)
+	require.ErrorIs(t, err, ErrNotPortable)
+	_, err = Unmarshal[int, int](data)
+	require.ErrorIs(t, err, ErrNotPortable)
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	m := MustNewMap(KV[string, int64]{"a", 1}, KV[string, int64]{"bc", 2}, KV[string, int64]{"def", 3})
+	data, err := m.MarshalBinary()
+	require.NoError(f, err)
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m, err := Unmarshal[string, int64](data)
+		if err != nil {
+			require.ErrorIs(t, err, ErrCorrupted)
+			return
+		}
+
+		// corrupted data may pass the checks,
+		// but lookups must stay in bounds
+		for k := range m.Entries() {
+			m.Lookup(k)
+		}
+		m.Lookup("missing")
+	})
+}
@@ -0,0 +1,265 @@
+package static
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+)
+
+const (
+	// mphfBucketSize is average number of keys per bucket.
+	// Larger buckets give less bits per key, but pilots
+	// for them are harder to find.
+	mphfBucketSize = 4
+
+	// mphfLoadFactor is load factor of the intermediate table.
+	// Positions beyond the number of keys are remapped into
+	// free slots, so the resulting hash is still minimal.
+	mphfLoadFactor = 0.97
+
+	// mphfFallbackLoadFactor is used after mphfMaxSeeds
+	// failed attempts. Pilots are found much faster, while
+	// the remap takes about one more word per two keys.
+	mphfFallbackLoadFactor = 0.5
+
+	// mphfMaxPilot bounds the search of pilot for every
+	// bucket, and mphfMaxSeeds bounds the number of full
+	// build attempts with each load factor, so the build
+	// time is O(N) with constant at most
+	// 2 * mphfMaxSeeds * mphfMaxPilot.
+	mphfMaxPilot = 1 << 16
+	mphfMaxSeeds = 16
+)
+
+// mphf is minimal perfect hash function in the style of PTHash:
+// keys are split into buckets by their hashes, and buckets, from
+// the largest one, search for the pilot, which places all their
+// keys into free slots of the table. Only pilots are stored, so
+// the function takes a few bits per key.
+type mphf struct {
+	seed   uint64
+	n      uint64
+	size   uint64
+	pilots packed
+	remap  packed
+}
+
+const (
+	errHashCollision = Error("static: hash collision")
+	errNoPilot       = Error("static: pilot search budget exceeded")
+)
+
+// newMPHF builds the function for given hashes. It returns
+// errHashCollision, if some hashes are equal, and errNoPilot,
+// if pilot of some bucket can't be found within the budget.
+func newMPHF(hashes []uint64, seed uint64, loadFactor float64) (mphf, error) {
+	n := uint64(len(hashes))
+	f := mphf{
+		seed: seed,
+		n:    n,
+		size: max(n, uint64(float64(n)/loadFactor)),
+	}
+
+	nb := (n + mphfBucketSize - 1) / mphfBucketSize
+	buckets := make([][]uint64, nb)
+	for _, h := range hashes {
+		b := f.bucket(h, nb)
+		buckets[b] = append(buckets[b], h)
+	}
+
+	order := make([]uint64, nb)
+	for i := range order {
+		order[i] = uint64(i)
+	}
+	slices.SortStableFunc(order, func(i, j uint64) int {
+		return len(buckets[j]) - len(buckets[i])
+	})
+
+	pilots := make([]uint64, nb)
+	taken := make([]uint64, (f.size+63)/64)
+	positions := make([]uint64, 0, mphfBucketSize)
+	for _, b := range order {
+		if len(buckets[b]) == 0 {
+			break
+		}
+
+		for i, h := range buckets[b] {
+			if slices.Contains(buckets[b][i+1:], h) {
+				return f, errHashCollision
+			}
+		}
+
+		pilot, ok := f.place(buckets[b], taken, positions)
+		if !ok {
+			return f, errNoPilot
+		}
+		pilots[b] = pilot
+	}
+
+	f.pilots = newPacked(pilots)
+	f.remap = newPacked(freeSlots(taken, n, f.size))
+	return f, nil
+}
+
+// place searches the pilot for the bucket and marks its slots as taken.
+func (f *mphf) place(bucket []uint64, taken []uint64, positions []uint64) (uint64, bool) {
+	for pilot := range uint64(mphfMaxPilot) {
+		positions = positions[:0]
+		for _, h := range bucket {
+			p := f.position(h, pilot)
+			if taken[p/64]&(1<<(p%64)) != 0 || slices.Contains(positions, p) {
+				break
+			}
+			positions = append(positions, p)
+		}
+
+		if len(positions) == len(bucket) {
+			for _, p := range positions {
+				taken[p/64] |= 1 << (p % 64)
+			}
+			return pilot, true
+		}
+	}
+	return 0, false
+}
+
+// freeSlots returns for every position in [n, size) some
+// slot in [0, n), which is not taken by any key. Number of
+// free slots in [0, n) equals number of taken positions in
+// [n, size), so every taken position gets its own slot.
+func freeSlots(taken []uint64, n, size uint64) []uint64 {
+	remap := make([]uint64, size-n)
+	free := uint64(0)
+	for p := n; p < size; p++ {
+		if taken[p/64]&(1<<(p%64)) == 0 {
+			continue
+		}
+		for taken[free/64]&(1<<(free%64)) != 0 {
+			free++
+		}
+		remap[p-n] = free
+		free++
+	}
+	return remap
+}
+
+// index returns slot in [0, n) for the hash of the key.
+func (f *mphf) index(h uint64) uint64 {
+	nb := (f.n + mphfBucketSize - 1) / mphfBucketSize
+	p := f.position(h, f.pilots.get(f.bucket(h, nb)))
+	if p >= f.n {
+		p = f.remap.get(p - f.n)
+	}
+	return p
+}
+
+func (f *mphf) bucket(h, nb uint64) uint64 {
+	return fastrange(mix(h^f.seed), nb)
+}
+
+func (f *mphf) position(h, pilot uint64) uint64 {
+	return fastrange(mix(h^mix(pilot+f.seed)), f.size)
+}
+
+// valid checks decoded function, so lookups stay in bounds.
+func (f *mphf) valid() bool {
+	// builder never makes load factor less than the fallback one
+	if f.size < f.n || f.size > uint64(float64(f.n)/mphfFallbackLoadFactor) {
+		return false
+	}
+
+	// free positions are remapped to distinct slots, so
+	// only a single one may be remapped by empty remap
+	if f.remap.width == 0 && f.size-f.n > 1 {
+		return false
+	}
+
+	nb := (f.n + mphfBucketSize - 1) / mphfBucketSize
+	if !f.pilots.holds(nb) || !f.remap.holds(f.size-f.n) {
+		return false
+	}
+
+	for i := range f.size - f.n {
+		if f.remap.get(i) >= f.n {
+			return false
+		}
+	}
+	return true
+}
+
+// bits returns number of bits, which the function takes.
+func (f *mphf) bits() int {
+	return f.pilots.bits() + f.remap.bits()
+}
+
+func newSeed() uint64 {
+	return rand.Uint64()
+}
+
+// mix is the finalizer of MurmurHash3.
+func mix(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// fastrange maps x to [0, n) without division.
+func fastrange(x, n uint64) uint64 {
+	hi, _ := bits.Mul64(x, n)
+	return hi
+}
+
+// packed is array of integers of the same bit width.
+type packed struct {
+	width uint8
+	words []uint64
+}
+
+func newPacked(xs []uint64) packed {
+	var width uint8
+	for _, x := range xs {
+		width = max(width, uint8(bits.Len64(x)))
+	}
+
+	p := packed{width: width}
+	if width == 0 {
+		return p
+	}
+
+	p.words = make([]uint64, (uint64(len(xs))*uint64(width)+63)/64+1)
+	for i, x := range xs {
+		pos := uint64(i) * uint64(width)
+		w, off := pos/64, pos%64
+		p.words[w] |= x << off
+		if off+uint64(width) > 64 {
+			p.words[w+1] |= x >> (64 - off)
+		}
+	}
+	return p
+}
+
+func (p *packed) get(i uint64) uint64 {
+	if p.width == 0 {
+		return 0
+	}
+
+	pos := i * uint64(p.width)
+	w, off := pos/64, pos%64
+	x := p.words[w] >> off
+	if off+uint64(p.width) > 64 {
+		x |= p.words[w+1] << (64 - off)
+	}
+	return x & (1<<p.width - 1)
+}
+
+// holds reports whether p has room for n integers.
+func (p *packed) holds(n uint64) bool {
+	return p.width == 0 || uint64(len(p.words))*64 >= n*uint64(p.width)
+}
+
+func (p *packed) bits() int {
+	return 64 * len(p.words)
+}
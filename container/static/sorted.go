@@ -0,0 +1,182 @@
+package static
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+// UNSTABLE
+type SortedMap[K cmp.Ordered, V any] = *smap[K, V]
+
+// NewSortedMap builds immutable map ordered by keys.
+// Like NewMap, it returns ErrDuplicateKey, if some key
+// is duplicated.
+//
+// UNSTABLE
+func NewSortedMap[K cmp.Ordered, V any](entries ...KV[K, V]) (SortedMap[K, V], error) {
+	sorted := slices.Clone(entries)
+	slices.SortFunc(sorted, func(a, b KV[K, V]) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+
+	m := &smap[K, V]{
+		keys: make([]K, 0, len(sorted)),
+		vals: make([]V, 0, len(sorted)),
+	}
+	for i, kv := range sorted {
+		if i > 0 && sorted[i-1].Key == kv.Key {
+			return nil, fmt.Errorf("%w: %v", ErrDuplicateKey, kv.Key)
+		}
+		m.keys = append(m.keys, kv.Key)
+		m.vals = append(m.vals, kv.Val)
+	}
+
+	m.tree = make([]K, len(m.keys)+1)
+	m.rank = make([]int, len(m.keys)+1)
+	m.layout(0, 1)
+	return m, nil
+}
+
+// MustNewSortedMap is like NewSortedMap, but panics on error.
+//
+// UNSTABLE
+func MustNewSortedMap[K cmp.Ordered, V any](entries ...KV[K, V]) SortedMap[K, V] {
+	m, err := NewSortedMap(entries...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// smap keeps sorted keys and values for ordered queries and a
+// copy of keys in Eytzinger layout for searches: node k has
+// children 2k and 2k+1, so the first levels of the tree share
+// a few cache lines, and the search has no unpredictable
+// branches.
+type smap[K cmp.Ordered, V any] struct {
+	keys []K
+	vals []V
+	tree []K
+	rank []int
+}
+
+// layout fills the subtree of node k with sorted keys starting
+// from i in order of in-order traversal and returns next key.
+func (m *smap[K, V]) layout(i, k int) int {
+	if k < len(m.tree) {
+		i = m.layout(i, 2*k)
+		m.tree[k], m.rank[k] = m.keys[i], i
+		i = m.layout(i+1, 2*k+1)
+	}
+	return i
+}
+
+// search returns index of the first key, which is not less than key.
+func (m *smap[K, V]) search(key K) int {
+	k := 1
+	for k < len(m.tree) {
+		k = 2*k + btoi(m.tree[k] < key)
+	}
+
+	// go up while moving left, and then once more
+	k >>= bits.TrailingZeros(^uint(k)) + 1
+	if k == 0 {
+		return len(m.keys)
+	}
+	return m.rank[k]
+}
+
+func (m *smap[K, V]) Len() int {
+	return len(m.keys)
+}
+
+func (m *smap[K, V]) HasKey(key K) bool {
+	_, ok := m.Lookup(key)
+	return ok
+}
+
+func (m *smap[K, V]) Lookup(key K) (V, bool) {
+	if i := m.search(key); i < len(m.keys) && m.keys[i] == key {
+		return m.vals[i], true
+	}
+	return *new(V), false
+}
+
+func (m *smap[K, V]) Get(key K) V {
+	v, _ := m.Lookup(key)
+	return v
+}
+
+// Entries iterates over all entries in ascending order of keys.
+func (m *smap[K, V]) Entries() iter.Seq2[K, V] {
+	return m.between(0, len(m.keys))
+}
+
+// Rank returns number of keys, which are less than key.
+func (m *smap[K, V]) Rank(key K) int {
+	return m.search(key)
+}
+
+// Select returns the entry with i-th smallest key, starting from 0.
+func (m *smap[K, V]) Select(i int) (K, V) {
+	return m.keys[i], m.vals[i]
+}
+
+// Range iterates over entries with keys in [lo, hi) in ascending order.
+func (m *smap[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return m.between(m.search(lo), m.search(hi))
+}
+
+// Floor returns the entry with the greatest key, which is not greater than key.
+func (m *smap[K, V]) Floor(key K) (K, V, bool) {
+	i := m.search(key)
+	if i == len(m.keys) || m.keys[i] != key {
+		i--
+	}
+	return m.at(i)
+}
+
+// Ceiling returns the entry with the least key, which is not less than key.
+func (m *smap[K, V]) Ceiling(key K) (K, V, bool) {
+	return m.at(m.search(key))
+}
+
+func (m *smap[K, V]) at(i int) (K, V, bool) {
+	if i < 0 || i >= len(m.keys) {
+		return *new(K), *new(V), false
+	}
+	return m.keys[i], m.vals[i], true
+}
+
+func (m *smap[K, V]) between(from, to int) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := from; i < to; i++ {
+			if !yield(m.keys[i], m.vals[i]) {
+				return
+			}
+		}
+	}
+}
+
+// PrefixScan iterates over entries, which keys start
+// with prefix, in ascending order of keys.
+func PrefixScan[K ~string, V any](m SortedMap[K, V], prefix K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := m.search(prefix); i < len(m.keys); i++ {
+			if !strings.HasPrefix(string(m.keys[i]), string(prefix)) || !yield(m.keys[i], m.vals[i]) {
+				return
+			}
+		}
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
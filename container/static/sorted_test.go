@@ -0,0 +1,102 @@
+package static
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikmy/algo/iterx"
+)
+
+func TestSortedMap(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+
+	for _, n := range []int{0, 1, 2, 7, 100, 1023, 1024, 5000} {
+		t.Run(strconv.Itoa(n), func(t *testing.T) {
+			et := make(map[int]int)
+			for range n {
+				et[rnd.Intn(4*n)] = rnd.Int()
+			}
+
+			entries := make([]KV[int, int], 0, len(et))
+			for k, v := range et {
+				entries = append(entries, KV[int, int]{k, v})
+			}
+
+			m := MustNewSortedMap(entries...)
+			keys := slices.Sorted(maps.Keys(et))
+
+			require.Equal(t, len(keys), m.Len())
+			require.Equal(t, et, maps.Collect(m.Entries()))
+			require.Equal(t, keys, slices.Collect(iterx.Left(m.Entries())))
+
+			for i, k := range keys {
+				sk, sv := m.Select(i)
+				require.Equal(t, k, sk)
+				require.Equal(t, et[k], sv)
+			}
+
+			for q := -1; q <= 4*n+1; q++ {
+				rank := sort.SearchInts(keys, q)
+				require.Equal(t, rank, m.Rank(q))
+
+				v, ok := m.Lookup(q)
+				ev, eok := et[q]
+				require.Equal(t, eok, ok)
+				require.Equal(t, ev, v)
+
+				ck, _, ok := m.Ceiling(q)
+				require.Equal(t, rank < len(keys), ok)
+				if ok {
+					require.Equal(t, keys[rank], ck)
+				}
+
+				floor := rank - 1
+				if eok {
+					floor = rank
+				}
+				fk, _, ok := m.Floor(q)
+				require.Equal(t, floor >= 0, ok)
+				if ok {
+					require.Equal(t, keys[floor], fk)
+				}
+			}
+
+			lo, hi := n/3, n
+			var inRange []int
+			for _, k := range keys {
+				if lo <= k && k < hi {
+					inRange = append(inRange, k)
+				}
+			}
+			require.Equal(t, inRange, slices.Collect(iterx.Left(m.Range(lo, hi))))
+		})
+	}
+}
+
+func TestSortedMap_Duplicates(t *testing.T) {
+	_, err := NewSortedMap(KV[string, int]{"a", 1}, KV[string, int]{"b", 2}, KV[string, int]{"a", 3})
+	require.ErrorIs(t, err, ErrDuplicateKey)
+	require.ErrorContains(t, err, "duplicate key: a")
+
+	require.Panics(t, func() { MustNewSortedMap(KV[int, int]{1, 1}, KV[int, int]{1, 1}) })
+}
+
+func TestPrefixScan(t *testing.T) {
+	words := []string{"a", "ab", "abc", "abd", "b", "ba", "bab", "c"}
+	entries := make([]KV[string, int], 0, len(words))
+	for i, w := range words {
+		entries = append(entries, KV[string, int]{w, i})
+	}
+	m := MustNewSortedMap(entries...)
+
+	require.Equal(t, []string{"ab", "abc", "abd"}, slices.Collect(iterx.Left(PrefixScan(m, "ab"))))
+	require.Equal(t, []string{"b", "ba", "bab"}, slices.Collect(iterx.Left(PrefixScan(m, "b"))))
+	require.Equal(t, words, slices.Collect(iterx.Left(PrefixScan(m, ""))))
+	require.Empty(t, slices.Collect(iterx.Left(PrefixScan(m, "bb"))))
+}
@@ -1,84 +1,320 @@
 package topcache
 
-import "sync"
+import (
+	"cmp"
+	"math"
+	"sync"
 
-func New[K comparable](topSize int, windowLen int, gran int64) *cache[K] {
-	return &cache[K]{
-		ordered: newTopList[K](topSize),
-		heap:    make(map[K]*entry[K]),
-		gran:    gran,
-		wLen:    windowLen,
-	}
+	"github.com/nikmy/algo/container/heap"
+)
+
+var (
+	_ Cache[struct{}]   = new(Windowed[struct{}])
+	_ Cache[struct{}]   = new(Sharded[struct{}])
+	_ Tracker[struct{}] = new(SpaceSaving[struct{}])
+	_ Tracker[struct{}] = new(CountMin[struct{}])
+)
+
+// Tracker counts keys of the stream and keeps the top list
+// of the greatest counts. It is implemented both by exact
+// caches and by sketches, so they are interchangeable.
+type Tracker[K comparable] interface {
+	// Update adds delta to the count of key.
+	Update(key K, delta int64)
+
+	// Top returns at most k keys with the greatest counts.
+	Top(k int) []K
+
+	// TopWithCounts is like Top, but also returns counts of keys.
+	TopWithCounts(k int) []KeyCount[K]
+}
+
+// Cache is Tracker, which counts keys exactly over
+// sliding window of time.
+type Cache[K comparable] interface {
+	Tracker[K]
+
+	// Count returns count of key in the window.
+	Count(key K) int64
+
+	// Remove forgets the key.
+	Remove(key K)
+
+	// Reset forgets all keys.
+	Reset()
+
+	// Len returns number of tracked keys.
+	Len() int
 }
 
-type cache[K comparable] struct {
-	lock sync.RWMutex
+// KeyCount is the key with its count in the window.
+type KeyCount[K comparable] struct {
+	Key   K     `json:"key"`
+	Count int64 `json:"count"`
+}
+
+// New returns Windowed configured by options.
+func New[K comparable](opts ...Option) *Windowed[K] {
+	c := &Windowed[K]{cfg: newConfig(opts)}
+	c.gran = c.cfg.gran.Nanoseconds()
+	c.wLen = c.cfg.window
+	c.reset()
+	return c
+}
+
+// Windowed is Cache, which counts keys exactly. It stores
+// the window of counts per key, so its memory is linear in
+// number of keys having non-zero count, unless it is limited
+// by WithEviction. Keys with non-positive count are forgotten.
+type Windowed[K comparable] struct {
+	lock sync.Mutex
+	cfg  config
 
 	ordered *topList[K]
 	heap    map[K]*entry[K]
 
+	// small is max-heap of entries from heap map,
+	// so the best of them can replace the top one
+	small *heap.IndexedHeap[*entry[K]]
+
+	// least is min-heap of entries from heap map,
+	// if number of keys is limited
+	least *heap.IndexedHeap[*entry[K]]
+
+	// expiry holds keys having a bucket
+	// in the granule, in the same order
+	// as buckets of entry windows
+	expiry []granule[K]
+	now    int64
+
 	gran int64
 	wLen int
 }
 
-func (c *cache[K]) Top(k int) []K {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
+type granule[K comparable] struct {
+	utc  int64
+	keys []K
+}
+
+func (c *Windowed[K]) Top(k int) []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.advance(c.clock())
 	return c.ordered.Top(k)
 }
 
-func (c *cache[K]) Update(key K, delta int64, utc int64) {
+func (c *Windowed[K]) TopWithCounts(k int) []KeyCount[K] {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	e, isSmall := c.heap[key]
+	c.advance(c.clock())
+	return c.ordered.TopWithCounts(k)
+}
 
-	if !isSmall && !c.ordered.Has(key) {
-		e = c.newEntry(key, delta, utc)
+func (c *Windowed[K]) Count(key K) int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-		if e.Cnt <= c.ordered.Min() {
-			c.heap[key] = e
-			return
-		}
+	c.advance(c.clock())
+	if e, ok := c.lookup(key); ok {
+		return e.Cnt
+	}
+	return 0
+}
 
-		c.pushTop(e)
-		return
+func (c *Windowed[K]) Remove(key K) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.lookup(key); ok {
+		e.Cnt = 0
+		c.fix(e)
 	}
+}
+
+func (c *Windowed[K]) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.reset()
+}
+
+func (c *Windowed[K]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.heap) + len(c.ordered.data)
+}
+
+// Update adds delta to the count of key at the current time.
+// Counts of granules older than the window of the latest update
+// are expired. If the clock goes back out of the window, the
+// update is ignored.
+func (c *Windowed[K]) Update(key K, delta int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.update(key, delta, c.clock())
+}
 
-	if !isSmall {
-		c.ordered.Update(key, delta, utc)
-		// maybe there is an entry in heap
-		// having greater count, but we ignore
-		// this weird case for optimization
+func (c *Windowed[K]) update(key K, delta int64, utc int64) {
+	c.advance(utc)
+
+	utc = c.round(utc)
+	if utc <= c.limit() {
 		return
 	}
 
-	e.Cnt += delta
-	if e.Cnt < c.ordered.Min() {
+	e, ok := c.lookup(key)
+	if !ok {
+		if c.cfg.maxKeys > 0 && len(c.heap)+len(c.ordered.data) >= c.cfg.maxKeys {
+			c.evict()
+		}
+
+		e = &entry[K]{Key: key, w: newWindow(c.wLen, c.gran)}
+		c.pushSmall(e)
+	}
+
+	if e.update(delta, utc) {
+		g := &c.expiry[e.w.slot(utc)]
+		if g.utc != utc {
+			g.utc, g.keys = utc, g.keys[:0]
+		}
+		g.keys = append(g.keys, key)
+	}
+
+	c.fix(e)
+}
+
+// snapshot moves the window to the current
+// time and returns the whole top list.
+func (c *Windowed[K]) snapshot() []KeyCount[K] {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.advance(c.clock())
+	return c.ordered.TopWithCounts(c.cfg.topSize)
+}
+
+func (c *Windowed[K]) reset() {
+	c.ordered = newTopList[K](c.cfg.topSize)
+	c.heap = make(map[K]*entry[K])
+	c.small = heap.NewIndexed(func(a, b *entry[K]) int {
+		return cmp.Compare(b.Cnt, a.Cnt)
+	})
+	if c.cfg.maxKeys > 0 {
+		c.least = heap.NewIndexed(func(a, b *entry[K]) int {
+			return cmp.Compare(a.Cnt, b.Cnt)
+		})
+	}
+
+	c.expiry = make([]granule[K], c.wLen)
+	for i := range c.expiry {
+		c.expiry[i].utc = noGranule
+	}
+	c.now = math.MinInt64
+}
+
+// advance moves the window to utc and expires the
+// buckets of granules, which have left the window.
+func (c *Windowed[K]) advance(utc int64) {
+	utc = c.round(utc)
+	if utc <= c.now {
 		return
 	}
+	c.now = utc
 
-	delete(c.heap, key)
-	c.pushTop(e)
+	limit := c.limit()
+	for i := range c.expiry {
+		g := &c.expiry[i]
+		if g.utc == noGranule || g.utc > limit {
+			continue
+		}
+
+		for _, key := range g.keys {
+			if e, ok := c.lookup(key); ok {
+				e.expire(limit)
+				c.fix(e)
+			}
+		}
+		g.utc, g.keys = noGranule, g.keys[:0]
+	}
+}
+
+func (c *Windowed[K]) lookup(key K) (*entry[K], bool) {
+	if e, ok := c.heap[key]; ok {
+		return e, true
+	}
+	return c.ordered.Get(key)
+}
+
+// fix restores the order after count of e has been changed
+// and moves the greatest of small entries into the top list.
+func (c *Windowed[K]) fix(e *entry[K]) {
+	switch _, top := c.ordered.Get(e.Key); {
+	case e.Cnt <= 0 && top:
+		c.ordered.Remove(e.Key)
+	case e.Cnt <= 0:
+		c.removeSmall(e)
+	case top:
+		c.ordered.Fix(e.Key)
+	default:
+		c.small.Fix(e.handle)
+		if c.least != nil {
+			c.least.Fix(e.least)
+		}
+	}
+
+	for !c.small.Empty() {
+		best := c.small.Min()
+		if c.ordered.Full() && best.Cnt <= c.ordered.Min() {
+			break
+		}
+
+		c.removeSmall(best)
+		if evicted := c.ordered.Push(best); evicted != nil {
+			c.pushSmall(evicted)
+		}
+	}
+}
+
+// evict forgets the small entry with the least count.
+func (c *Windowed[K]) evict() {
+	if !c.least.Empty() {
+		c.removeSmall(c.least.Min())
+	}
 }
 
-func (c *cache[K]) newEntry(key K, cnt int64, utc int64) *entry[K] {
-	w := window{
-		gran: c.gran,
-		data: make([]item, c.wLen),
+func (c *Windowed[K]) pushSmall(e *entry[K]) {
+	c.heap[e.Key] = e
+	e.handle = c.small.Push(e)
+	if c.least != nil {
+		e.least = c.least.Push(e)
 	}
-	w.Update(cnt, utc)
+}
 
-	return &entry[K]{
-		Key: key,
-		Cnt: cnt,
-		w:   w,
+func (c *Windowed[K]) removeSmall(e *entry[K]) {
+	delete(c.heap, e.Key)
+	c.small.Remove(e.handle)
+	if c.least != nil {
+		c.least.Remove(e.least)
 	}
 }
 
-func (c *cache[K]) pushTop(e *entry[K]) {
-	evicted := c.ordered.Push(e)
-	if evicted != nil {
-		c.heap[evicted.Key] = evicted
+// limit is the start of the latest expired granule.
+func (c *Windowed[K]) limit() int64 {
+	if c.now == math.MinInt64 {
+		return math.MinInt64
 	}
+	return c.now - c.gran*int64(c.wLen)
+}
+
+func (c *Windowed[K]) round(t int64) int64 {
+	r := t / c.gran * c.gran
+	if r > t {
+		r -= c.gran
+	}
+	return r
+}
+
+func (c *Windowed[K]) clock() int64 {
+	return c.cfg.clock.Now().UnixNano()
 }
@@ -0,0 +1,157 @@
+package topcache
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowed_Window(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	c := New[string](
+		WithTopSize(2),
+		WithWindow(3),
+		WithGranularity(10*time.Second),
+		WithClock(clock),
+	)
+
+	update := func(key string, delta int64, sec int64) {
+		clock.Set(time.Unix(sec, 0))
+		c.Update(key, delta)
+	}
+
+	update("a", 5, 0)
+	update("b", 3, 12)
+	update("c", 1, 25)
+	require.Equal(t, []KeyCount[string]{{"a", 5}, {"b", 3}}, c.TopWithCounts(3))
+	require.Equal(t, 3, c.Len())
+
+	// granule [0, 10) leaves the window
+	update("c", 1, 30)
+	require.Equal(t, []KeyCount[string]{{"b", 3}, {"c", 2}}, c.TopWithCounts(3))
+	require.NotContains(t, c.heap, "a")
+	require.Zero(t, c.Count("a"))
+
+	update("a", 1, 5)
+	require.Equal(t, []string{"b", "c"}, c.Top(3))
+
+	// expiry doesn't need updates
+	clock.Set(time.Unix(45, 0))
+	require.Equal(t, []KeyCount[string]{{"c", 2}}, c.TopWithCounts(3))
+	require.Equal(t, int64(2), c.Count("c"))
+	require.Equal(t, 1, c.Len())
+}
+
+func TestWindowed_NegativeTime(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, -1))
+	c := New[int](WithTopSize(2), WithWindow(3), WithGranularity(1), WithClock(clock))
+
+	c.Update(1, 2)
+	clock.Set(time.Unix(0, -3))
+	c.Update(2, 1)
+	require.Equal(t, []KeyCount[int]{{1, 2}, {2, 1}}, c.TopWithCounts(2))
+
+	// granule -3 leaves the window
+	clock.Set(time.Unix(0, 1))
+	c.Update(2, 1)
+	require.Equal(t, []KeyCount[int]{{1, 2}, {2, 1}}, c.TopWithCounts(2))
+
+	clock.Set(time.Unix(0, 2))
+	c.Update(3, 5)
+	require.Equal(t, []KeyCount[int]{{3, 5}, {2, 1}}, c.TopWithCounts(2))
+	require.Empty(t, c.heap)
+}
+
+func TestWindowed_RemoveReset(t *testing.T) {
+	c := New[int](WithTopSize(2), WithClock(NewManualClock(time.Unix(0, 0))))
+	for key := range 5 {
+		c.Update(key, int64(key+1))
+	}
+	require.Equal(t, []int{4, 3}, c.Top(5))
+
+	c.Remove(4)
+	c.Remove(0)
+	c.Remove(42)
+	require.Equal(t, []int{3, 2}, c.Top(5))
+	require.Equal(t, 3, c.Len())
+	require.Zero(t, c.Count(4))
+
+	c.Reset()
+	require.Zero(t, c.Len())
+	require.Empty(t, c.Top(5))
+
+	c.Update(1, 1)
+	require.Equal(t, []KeyCount[int]{{1, 1}}, c.TopWithCounts(5))
+}
+
+func TestWindowed_Eviction(t *testing.T) {
+	c := New[int](WithTopSize(2), WithEviction(4), WithClock(NewManualClock(time.Unix(0, 0))))
+	for key := range 10 {
+		c.Update(key, int64(10-key))
+	}
+
+	require.Equal(t, 4, c.Len())
+	require.Equal(t, []int{0, 1}, c.Top(2))
+	require.Equal(t, int64(8), c.Count(2))
+	require.Equal(t, int64(1), c.Count(9))
+	require.Zero(t, c.Count(3))
+}
+
+func TestWindowed(t *testing.T) {
+	const topSize, wLen, gran = 5, 4, 10
+
+	type event struct {
+		key        int
+		delta, utc int64
+	}
+
+	clock := NewManualClock(time.Unix(0, 0))
+	c := New[int](
+		WithTopSize(topSize),
+		WithWindow(wLen),
+		WithGranularity(gran),
+		WithClock(clock),
+	)
+	rnd := rand.New(rand.NewSource(42))
+
+	var events []event
+	now, latest := int64(0), int64(0)
+	for range 20_000 {
+		now += rnd.Int63n(3)
+		e := event{key: rnd.Intn(30), delta: rnd.Int63n(10) + 1, utc: max(0, now-rnd.Int63n(gran))}
+		events = append(events, e)
+		clock.Set(time.Unix(0, e.utc))
+		c.Update(e.key, e.delta)
+
+		latest = max(latest, e.utc)
+		start := latest/gran*gran - gran*(wLen-1)
+		counts := map[int]int64{}
+		for _, e := range events {
+			if e.utc >= start {
+				counts[e.key] += e.delta
+			}
+		}
+
+		expected := make([]int64, 0, len(counts))
+		for _, cnt := range counts {
+			expected = append(expected, cnt)
+		}
+		slices.SortFunc(expected, func(a, b int64) int { return cmp.Compare(b, a) })
+		expected = expected[:min(topSize, len(expected))]
+
+		top := c.TopWithCounts(topSize)
+		actual := make([]int64, 0, len(top))
+		for _, kc := range top {
+			require.Equal(t, counts[kc.Key], kc.Count)
+			actual = append(actual, kc.Count)
+		}
+		require.Equal(t, expected, actual)
+		require.Equal(t, len(counts), c.Len())
+
+		events = slices.DeleteFunc(events, func(e event) bool { return e.utc < start })
+	}
+}
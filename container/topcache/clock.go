@@ -0,0 +1,47 @@
+package topcache
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of updates.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewManualClock returns ManualClock showing given time.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// ManualClock is Clock, which is moved only explicitly,
+// so tests can drive time deterministically.
+type ManualClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) Set(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = now
+}
+
+func (c *ManualClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,162 @@
+package topcache
+
+import (
+	"cmp"
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"slices"
+	"sync"
+
+	"github.com/nikmy/algo/container/heap"
+)
+
+// NewCountMin returns CountMin of depth rows of width counters,
+// which tracks topSize keys with the greatest estimates.
+//
+// For error epsilon*N with probability 1-delta, choose width
+// as ceil(e/epsilon) and depth as ceil(ln(1/delta)), see
+// CountMinSize.
+func NewCountMin[K comparable](width, depth, topSize int) *CountMin[K] {
+	if width <= 0 || depth <= 0 || topSize <= 0 {
+		panic("count-min dimensions must be positive")
+	}
+
+	return &CountMin[K]{
+		seed:  maphash.MakeSeed(),
+		width: width,
+		rows:  make([]int64, width*depth),
+		size:  topSize,
+		index: make(map[K]heap.Handle[*counter[K]], topSize),
+		top: heap.NewIndexed(func(a, b *counter[K]) int {
+			return cmp.Compare(a.cnt, b.cnt)
+		}),
+	}
+}
+
+// CountMinSize returns width and depth of CountMin, which
+// overestimates counts by at most epsilon*N with probability
+// at least 1-delta.
+func CountMinSize(epsilon, delta float64) (width, depth int) {
+	width = int(math.Ceil(math.E / epsilon))
+	depth = int(math.Ceil(math.Log(1 / delta)))
+	return width, max(depth, 1)
+}
+
+// CountMin is Count-Min Sketch with min-heap of heavy hitters.
+// The sketch takes width*depth counters regardless of number
+// of distinct keys, and only the top keys are stored.
+//
+// Let N be the sum of all deltas. Then estimated count of every
+// key is not less than the true one, and it overestimates by
+// more than e*N/width with probability at most exp(-depth).
+// Keys, which estimated count is among topSize greatest ones at
+// the moment of their update, are kept in the top.
+//
+// The sketch uses conservative update, i.e. it increments only
+// counters, which are less than the new estimate, so counts
+// can't be decreased and non-positive deltas are ignored.
+type CountMin[K comparable] struct {
+	lock sync.RWMutex
+
+	seed  maphash.Seed
+	width int
+	rows  []int64
+	total int64
+
+	size  int
+	index map[K]heap.Handle[*counter[K]]
+	top   *heap.IndexedHeap[*counter[K]]
+}
+
+func (s *CountMin[K]) Update(key K, delta int64) {
+	if delta <= 0 {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.total += delta
+	cnt := s.estimate(key) + delta
+	s.counters(key, func(c *int64) { *c = max(*c, cnt) })
+
+	if h, ok := s.index[key]; ok {
+		s.top.Peek(h).cnt = cnt
+		s.top.Fix(h)
+		return
+	}
+
+	if s.top.Size() < s.size {
+		s.index[key] = s.top.Push(&counter[K]{key: key, cnt: cnt})
+		return
+	}
+
+	if m := s.top.Min(); cnt > m.cnt {
+		s.top.Pop()
+		delete(s.index, m.key)
+		m.key, m.cnt = key, cnt
+		s.index[key] = s.top.Push(m)
+	}
+}
+
+// Count returns estimated count of key.
+func (s *CountMin[K]) Count(key K) int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.estimate(key)
+}
+
+// Total returns the sum of all deltas.
+func (s *CountMin[K]) Total() int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.total
+}
+
+func (s *CountMin[K]) Top(k int) []K {
+	top := s.TopWithCounts(k)
+
+	r := make([]K, 0, len(top))
+	for _, kc := range top {
+		r = append(r, kc.Key)
+	}
+	return r
+}
+
+// TopWithCounts returns k keys with the greatest estimates.
+func (s *CountMin[K]) TopWithCounts(k int) []KeyCount[K] {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	r := make([]KeyCount[K], 0, len(s.index))
+	for _, h := range s.index {
+		c := s.top.Peek(h)
+		r = append(r, KeyCount[K]{Key: c.key, Count: c.cnt})
+	}
+
+	slices.SortFunc(r, func(a, b KeyCount[K]) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	return r[:min(k, len(r))]
+}
+
+func (s *CountMin[K]) estimate(key K) int64 {
+	cnt := int64(math.MaxInt64)
+	s.counters(key, func(c *int64) { cnt = min(cnt, *c) })
+	return cnt
+}
+
+// counters calls f for the counter of key in each row.
+// Row indices are derived from a single hash by double
+// hashing, which keeps the error bounds.
+func (s *CountMin[K]) counters(key K, f func(*int64)) {
+	h := maphash.Comparable(s.seed, key)
+	h1, h2 := h, bits.RotateLeft64(h, 32)|1
+
+	for row := 0; row < len(s.rows); row += s.width {
+		hi, _ := bits.Mul64(h1, uint64(s.width))
+		f(&s.rows[row+int(hi)])
+		h1 += h2
+	}
+}
@@ -1,13 +1,27 @@
 package topcache
 
+import "github.com/nikmy/algo/container/heap"
+
 type entry[K comparable] struct {
 	Key K
 	Cnt int64
 
 	w window
+
+	// handles are valid, while entry is not in top list
+	handle heap.Handle[*entry[K]]
+	least  heap.Handle[*entry[K]]
+}
+
+// update adds delta at granule starting at utc and returns
+// whether the bucket is new. Cnt stays equal to the sum of
+// buckets in the window.
+func (e *entry[K]) update(delta, utc int64) bool {
+	expired, created := e.w.Update(delta, utc)
+	e.Cnt += delta - expired
+	return created
 }
 
-func (e *entry[K]) update(delta, utc int64) {
-	e.w.Update(delta, utc)
-	e.Cnt += delta
+func (e *entry[K]) expire(limit int64) {
+	e.Cnt -= e.w.Expire(limit)
 }
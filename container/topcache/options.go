@@ -0,0 +1,64 @@
+package topcache
+
+import "time"
+
+type Option func(*config)
+
+type config struct {
+	topSize int
+	window  int
+	gran    time.Duration
+	maxKeys int
+	clock   Clock
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		topSize: 10,
+		window:  60,
+		gran:    time.Second,
+		clock:   systemClock{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.topSize <= 0 || cfg.window <= 0 || cfg.gran <= 0 {
+		panic("top size, window and granularity must be positive")
+	}
+	if cfg.maxKeys > 0 {
+		cfg.maxKeys = max(cfg.maxKeys, cfg.topSize+1)
+	}
+	return cfg
+}
+
+// WithTopSize sets number of keys in the top list, 10 by default.
+func WithTopSize(n int) Option {
+	return func(c *config) { c.topSize = n }
+}
+
+// WithWindow sets length of the sliding window in granules,
+// 60 by default.
+func WithWindow(granules int) Option {
+	return func(c *config) { c.window = granules }
+}
+
+// WithGranularity sets length of the granule, one second by
+// default. Counts expire granule by granule, so the window
+// covers from (window-1)*gran to window*gran of time.
+func WithGranularity(gran time.Duration) Option {
+	return func(c *config) { c.gran = gran }
+}
+
+// WithEviction limits number of tracked keys. When a new key
+// comes, the key with the least count outside the top list
+// is evicted. The limit is at least top size plus one. By
+// default, keys are evicted only when their count expires.
+func WithEviction(maxKeys int) Option {
+	return func(c *config) { c.maxKeys = maxKeys }
+}
+
+// WithClock sets the source of time, the system clock by default.
+func WithClock(clock Clock) Option {
+	return func(c *config) { c.clock = clock }
+}
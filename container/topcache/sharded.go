@@ -0,0 +1,99 @@
+package topcache
+
+import (
+	"hash/maphash"
+	"runtime"
+)
+
+// NewSharded returns Sharded of given number of shards, each of
+// them is Windowed configured by options. Non-positive number
+// of shards means twice the GOMAXPROCS.
+func NewSharded[K comparable](shards int, opts ...Option) *Sharded[K] {
+	if shards <= 0 {
+		shards = 2 * runtime.GOMAXPROCS(0)
+	}
+
+	s := &Sharded[K]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]shard[K], shards),
+		cfg:    newConfig(opts),
+	}
+	for i := range s.shards {
+		s.shards[i].c = New[K](opts...)
+	}
+	return s
+}
+
+// Sharded is Cache, which scales for concurrent updates. Keys
+// are spread among shards by hash, so updates of distinct keys
+// rarely contend for the same lock. Each key is counted by the
+// single shard, so the top of all shards is exactly the top of
+// the union of their top lists. Eviction limits number of keys
+// per shard.
+type Sharded[K comparable] struct {
+	seed   maphash.Seed
+	shards []shard[K]
+	cfg    config
+}
+
+type shard[K comparable] struct {
+	c *Windowed[K]
+	_ [64]byte
+}
+
+func (s *Sharded[K]) Update(key K, delta int64) {
+	s.shard(key).Update(key, delta)
+}
+
+func (s *Sharded[K]) Count(key K) int64 {
+	return s.shard(key).Count(key)
+}
+
+func (s *Sharded[K]) Remove(key K) {
+	s.shard(key).Remove(key)
+}
+
+func (s *Sharded[K]) Reset() {
+	for i := range s.shards {
+		s.shards[i].c.Reset()
+	}
+}
+
+func (s *Sharded[K]) Len() int {
+	n := 0
+	for i := range s.shards {
+		n += s.shards[i].c.Len()
+	}
+	return n
+}
+
+// Snapshot returns top list of all shards at the current time.
+func (s *Sharded[K]) Snapshot() Snapshot[K] {
+	snap := Snapshot[K]{Time: s.cfg.clock.Now()}
+	for i := range s.shards {
+		snap.Top = append(snap.Top, s.shards[i].c.snapshot()...)
+	}
+
+	snap.sort(s.cfg.topSize)
+	return snap
+}
+
+func (s *Sharded[K]) Top(k int) []K {
+	top := s.TopWithCounts(k)
+
+	r := make([]K, 0, len(top))
+	for _, kc := range top {
+		r = append(r, kc.Key)
+	}
+	return r
+}
+
+func (s *Sharded[K]) TopWithCounts(k int) []KeyCount[K] {
+	top := s.Snapshot().Top
+	return top[:min(k, len(top))]
+}
+
+func (s *Sharded[K]) shard(key K) *Windowed[K] {
+	h := maphash.Comparable(s.seed, key)
+	return s.shards[h%uint64(len(s.shards))].c
+}
@@ -0,0 +1,96 @@
+package topcache
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikmy/algo/syncx/atomx"
+	"github.com/nikmy/algo/testx/faulty"
+	"github.com/nikmy/algo/testx/synctest"
+)
+
+func TestSharded(t *testing.T) {
+	const keys, topSize = 50, 10
+
+	clock := NewManualClock(time.Unix(500, 0))
+	s := NewSharded[int](4, WithTopSize(topSize), WithWindow(4), WithClock(clock))
+
+	var counts [keys]atomx.Int64
+	update := synctest.Operation{
+		Runner: func() {
+			key := rand.Intn(keys)
+			delta := int64(key%7 + 1)
+			counts[key].Add(delta)
+			s.Update(key, delta)
+		},
+		Actors: 4,
+	}
+
+	snapshot := synctest.Operation{
+		Runner: func() {
+			top := s.Snapshot().Top
+			for i := 1; i < len(top); i++ {
+				require.GreaterOrEqual(t, top[i-1].Count, top[i].Count)
+			}
+		},
+		Actors: 1,
+	}
+
+	c := faulty.NewController(t, 42)
+	c.SetFaultProbability(0.3)
+
+	synctest.Stress(t, c.FaultInjector, 10_000, update, snapshot)
+
+	single := New[int](WithTopSize(topSize), WithClock(clock))
+	total := 0
+	for key := range counts {
+		if cnt := counts[key].Load(); cnt > 0 {
+			single.Update(key, cnt)
+			require.Equal(t, cnt, s.Count(key))
+			total++
+		}
+	}
+	require.Equal(t, total, s.Len())
+
+	snap := s.Snapshot()
+	require.Equal(t, clock.Now(), snap.Time)
+	require.Len(t, snap.Top, topSize)
+	for i, kc := range single.TopWithCounts(topSize) {
+		require.Equal(t, kc.Count, snap.Top[i].Count)
+		require.Equal(t, counts[snap.Top[i].Key].Load(), snap.Top[i].Count)
+	}
+
+	// the window has passed
+	clock.Advance(time.Hour)
+	s.Update(0, 1)
+	require.Equal(t, []KeyCount[int]{{0, 1}}, s.TopWithCounts(topSize))
+
+	s.Remove(0)
+	require.Empty(t, s.Top(topSize))
+	s.Update(1, 1)
+	s.Reset()
+	require.Zero(t, s.Len())
+}
+
+func TestMerge(t *testing.T) {
+	a := Snapshot[string]{Time: time.Unix(10, 0).UTC(), Top: []KeyCount[string]{{"x", 5}, {"y", 3}, {"z", 1}}}
+	b := Snapshot[string]{Time: time.Unix(20, 0).UTC(), Top: []KeyCount[string]{{"z", 6}, {"x", 2}}}
+
+	data, err := json.Marshal(b)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"time":"1970-01-01T00:00:20Z","top":[{"key":"z","count":6},{"key":"x","count":2}]}`, string(data))
+
+	var decoded Snapshot[string]
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, b, decoded)
+
+	merged := Merge(2, a, decoded)
+	require.Equal(t, Snapshot[string]{
+		Time: b.Time,
+		Top:  []KeyCount[string]{{"x", 7}, {"z", 7}},
+	}, merged)
+}
@@ -0,0 +1,104 @@
+package topcache
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func zipfStream(n int) []uint64 {
+	rnd := rand.New(rand.NewSource(42))
+	z := rand.NewZipf(rnd, 1.2, 1, 1_000_000)
+
+	stream := make([]uint64, n)
+	for i := range stream {
+		stream[i] = z.Uint64()
+	}
+	return stream
+}
+
+func TestSpaceSaving(t *testing.T) {
+	const counters, n = 100, 200_000
+
+	s := NewSpaceSaving[uint64](counters)
+	counts := map[uint64]int64{}
+	for _, key := range zipfStream(n) {
+		s.Update(key, 1)
+		counts[key]++
+	}
+	require.Equal(t, int64(n), s.Total())
+
+	top := s.TopWithCounts(counters)
+	require.Len(t, top, counters)
+
+	tracked := map[uint64]bool{}
+	for _, kc := range top {
+		tracked[kc.Key] = true
+
+		cnt, err := s.Count(kc.Key)
+		require.Equal(t, kc.Count, cnt)
+		require.LessOrEqual(t, err, int64(n/counters))
+		require.GreaterOrEqual(t, cnt, counts[kc.Key])
+		require.LessOrEqual(t, cnt-err, counts[kc.Key])
+	}
+
+	for key, cnt := range counts {
+		if cnt > n/counters {
+			require.True(t, tracked[key])
+		}
+	}
+
+	require.Equal(t, []uint64{0, 1, 2}, s.Top(3))
+}
+
+func TestCountMin(t *testing.T) {
+	const n = 200_000
+
+	width, depth := CountMinSize(0.001, 0.01)
+	s := NewCountMin[uint64](width, depth, 10)
+	counts := map[uint64]int64{}
+	for _, key := range zipfStream(n) {
+		s.Update(key, 1)
+		counts[key]++
+	}
+	require.Equal(t, int64(n), s.Total())
+
+	bad := 0
+	for key, cnt := range counts {
+		est := s.Count(key)
+		require.GreaterOrEqual(t, est, cnt)
+		if float64(est-cnt) > 0.001*n {
+			bad++
+		}
+	}
+	require.LessOrEqual(t, float64(bad), 0.01*float64(len(counts)))
+
+	top := s.TopWithCounts(10)
+	require.Len(t, top, 10)
+	for _, kc := range top {
+		require.GreaterOrEqual(t, kc.Count, counts[kc.Key])
+	}
+	require.Equal(t, []uint64{0, 1, 2}, s.Top(3))
+}
+
+func TestTracker(t *testing.T) {
+	width, depth := CountMinSize(0.01, 0.01)
+	for name, tracker := range map[string]Tracker[string]{
+		"windowed":     New[string](WithTopSize(2)),
+		"space saving": NewSpaceSaving[string](2),
+		"count-min":    NewCountMin[string](width, depth, 2),
+	} {
+		t.Run(name, func(t *testing.T) {
+			tracker.Update("a", 3)
+			tracker.Update("b", 2)
+			tracker.Update("c", 0)
+			tracker.Update("a", -1)
+
+			top := tracker.TopWithCounts(3)
+			require.Equal(t, []string{"a", "b"}, tracker.Top(3))
+			require.Equal(t, "a", top[0].Key)
+			require.GreaterOrEqual(t, top[0].Count, int64(2))
+		})
+	}
+}
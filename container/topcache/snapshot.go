@@ -0,0 +1,56 @@
+package topcache
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Snapshot is the top list at the moment. It is serializable
+// by encoding/json, if keys are, so snapshots of different
+// processes can be merged into the global one.
+type Snapshot[K comparable] struct {
+	// Time is the moment of snapshot
+	Time time.Time `json:"time"`
+
+	// Top is sorted by count in descending order
+	Top []KeyCount[K] `json:"top"`
+}
+
+// Merge returns snapshot of at most topSize keys with the
+// greatest sum of counts over all snapshots.
+//
+// If the same key is counted by a few snapshots, but falls
+// out of the top list of some of them, its merged count is
+// less than the true one. So merged top is exact only for
+// snapshots of disjoint sets of keys, e.g. shards, and is
+// approximate otherwise.
+func Merge[K comparable](topSize int, snapshots ...Snapshot[K]) Snapshot[K] {
+	var merged Snapshot[K]
+
+	index := make(map[K]int)
+	for _, s := range snapshots {
+		if s.Time.After(merged.Time) {
+			merged.Time = s.Time
+		}
+		for _, kc := range s.Top {
+			if i, ok := index[kc.Key]; ok {
+				merged.Top[i].Count += kc.Count
+				continue
+			}
+
+			index[kc.Key] = len(merged.Top)
+			merged.Top = append(merged.Top, kc)
+		}
+	}
+
+	merged.sort(topSize)
+	return merged
+}
+
+func (s *Snapshot[K]) sort(topSize int) {
+	slices.SortStableFunc(s.Top, func(a, b KeyCount[K]) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	s.Top = s.Top[:min(topSize, len(s.Top))]
+}
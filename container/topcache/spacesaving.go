@@ -0,0 +1,133 @@
+package topcache
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"github.com/nikmy/algo/container/heap"
+)
+
+// NewSpaceSaving returns SpaceSaving of given number of counters.
+func NewSpaceSaving[K comparable](counters int) *SpaceSaving[K] {
+	if counters <= 0 {
+		panic("number of counters must be positive")
+	}
+
+	return &SpaceSaving[K]{
+		counters: counters,
+		index:    make(map[K]heap.Handle[*counter[K]], counters),
+		heap: heap.NewIndexed(func(a, b *counter[K]) int {
+			return cmp.Compare(a.cnt, b.cnt)
+		}),
+	}
+}
+
+// SpaceSaving tracks heavy hitters of the stream in memory
+// of fixed number of counters m. When a new key comes and all
+// counters are taken, it replaces the key with minimal count
+// c, and the new key inherits c as its error. It is the same
+// algorithm as Misra-Gries, which reports count minus error.
+//
+// Let N be the sum of all deltas. Then for every key:
+//   - reported count is not less than true count, and
+//     overestimates it by at most error <= N/m;
+//   - if true count is greater than N/m, the key is tracked,
+//     so Top(m) contains all such keys.
+//
+// Counts can't be decreased, so non-positive deltas are ignored.
+type SpaceSaving[K comparable] struct {
+	lock sync.RWMutex
+
+	counters int
+	index    map[K]heap.Handle[*counter[K]]
+	heap     *heap.IndexedHeap[*counter[K]]
+	total    int64
+}
+
+type counter[K comparable] struct {
+	key K
+	cnt int64
+	err int64
+}
+
+func (s *SpaceSaving[K]) Update(key K, delta int64) {
+	if delta <= 0 {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.total += delta
+
+	if h, ok := s.index[key]; ok {
+		s.heap.Peek(h).cnt += delta
+		s.heap.Fix(h)
+		return
+	}
+
+	if s.heap.Size() < s.counters {
+		s.index[key] = s.heap.Push(&counter[K]{key: key, cnt: delta})
+		return
+	}
+
+	c := s.heap.Pop()
+	delete(s.index, c.key)
+	c.key, c.cnt, c.err = key, c.cnt+delta, c.cnt
+	s.index[key] = s.heap.Push(c)
+}
+
+// Count returns the upper bound of count of key and the
+// maximum overestimation. For untracked keys it returns
+// the minimal counter, which is also the upper bound.
+func (s *SpaceSaving[K]) Count(key K) (cnt int64, err int64) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if h, ok := s.index[key]; ok {
+		c := s.heap.Peek(h)
+		return c.cnt, c.err
+	}
+
+	if s.heap.Size() < s.counters {
+		return 0, 0
+	}
+	m := s.heap.Min().cnt
+	return m, m
+}
+
+// Total returns the sum of all deltas.
+func (s *SpaceSaving[K]) Total() int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.total
+}
+
+func (s *SpaceSaving[K]) Top(k int) []K {
+	top := s.TopWithCounts(k)
+
+	r := make([]K, 0, len(top))
+	for _, kc := range top {
+		r = append(r, kc.Key)
+	}
+	return r
+}
+
+// TopWithCounts returns k keys with the greatest
+// counts, which are upper bounds of true ones.
+func (s *SpaceSaving[K]) TopWithCounts(k int) []KeyCount[K] {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	r := make([]KeyCount[K], 0, len(s.index))
+	for _, h := range s.index {
+		c := s.heap.Peek(h)
+		r = append(r, KeyCount[K]{Key: c.key, Count: c.cnt})
+	}
+
+	slices.SortFunc(r, func(a, b KeyCount[K]) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	return r[:min(k, len(r))]
+}
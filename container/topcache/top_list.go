@@ -23,10 +23,29 @@ func (l *topList[K]) Top(k int) []K {
 	return r
 }
 
+func (l *topList[K]) TopWithCounts(k int) []KeyCount[K] {
+	k = min(k, len(l.data))
+
+	r := make([]KeyCount[K], 0, k)
+	for _, entry := range l.data[:k] {
+		r = append(r, KeyCount[K]{Key: entry.Key, Count: entry.Cnt})
+	}
+
+	return r
+}
+
+// Min returns the least count in the list, or zero if it is empty.
 func (l *topList[K]) Min() int64 {
+	if len(l.data) == 0 {
+		return 0
+	}
 	return l.data[len(l.data)-1].Cnt
 }
 
+func (l *topList[K]) Full() bool {
+	return len(l.data) == cap(l.data)
+}
+
 func (l *topList[K]) Push(entry *entry[K]) (evicted *entry[K]) {
 	if len(l.data) == cap(l.data) && entry.Cnt <= l.Min() {
 		return entry
@@ -47,20 +66,37 @@ func (l *topList[K]) Push(entry *entry[K]) (evicted *entry[K]) {
 	return
 }
 
-func (l *topList[K]) Update(key K, delta int64, utc int64) int64 {
+// Fix restores the order after count of key has been changed.
+func (l *topList[K]) Fix(key K) {
 	i, ok := l.fidx[key]
 	if !ok {
-		panic("update: entry not found")
+		panic("fix: entry not found")
 	}
 
-	l.data[i].update(delta, utc)
 	l.sift(i)
-	return l.data[i].Cnt
 }
 
-func (l *topList[K]) Has(key K) bool {
-	_, ok := l.fidx[key]
-	return ok
+func (l *topList[K]) Remove(key K) *entry[K] {
+	i, ok := l.fidx[key]
+	if !ok {
+		return nil
+	}
+
+	e := l.data[i]
+	for ; i+1 < len(l.data); i++ {
+		l.swap(i, i+1)
+	}
+	l.data = l.data[:i]
+	delete(l.fidx, key)
+	return e
+}
+
+func (l *topList[K]) Get(key K) (*entry[K], bool) {
+	i, ok := l.fidx[key]
+	if !ok {
+		return nil, false
+	}
+	return l.data[i], true
 }
 
 func (l *topList[K]) sift(i int) {
@@ -1,11 +1,15 @@
 package topcache
 
-import "sort"
+import "math"
 
+// noGranule marks empty bucket
+const noGranule = math.MinInt64
+
+// window is ring of per-granule counts. A bucket of granule
+// starting at t lives in slot t/gran mod len, so slots of
+// granules within the window never collide.
 type window struct {
 	data []item
-	head int
-	size int
 	gran int64
 }
 
@@ -14,51 +18,41 @@ type item struct {
 	utc int64
 }
 
-func (w *window) Update(delta, utc int64) {
-	w.cleanup(utc)
-
-	at := w.search(utc)
-	if len(w.data) > 0 && at == len(w.data) {
-		w.popFront()
-	}
-
-	if at < w.size {
-		w.data[w.idx(at)].cnt += delta
-		return
+func newWindow(length int, gran int64) window {
+	data := make([]item, length)
+	for i := range data {
+		data[i].utc = noGranule
 	}
-
-	w.data[w.idx(at)] = item{cnt: delta, utc: w.round(utc)}
-	w.size++
+	return window{data: data, gran: gran}
 }
 
-func (w *window) cleanup(now int64) {
-	firstValid := w.idx(w.search(now - w.gran * int64(len(w.data))))
-	for w.head != firstValid {
-		w.popFront()
+// Update adds delta to the bucket of granule starting at utc,
+// which must be rounded. It returns the count of expired bucket
+// stored in the same slot, and whether the bucket is new.
+func (w *window) Update(delta, utc int64) (expired int64, created bool) {
+	b := &w.data[w.slot(utc)]
+	if b.utc != utc {
+		expired, created = b.cnt, true
+		*b = item{utc: utc}
 	}
-}
-
-func (w *window) search(t int64) int {
-	i := sort.Search(w.size-1, func(i int) bool {
-		return w.data[w.idx(i)].utc >= t
-	})
-
-	if (t - w.data[w.idx(i)].utc) >= w.gran {
-		i++
+	b.cnt += delta
+	return
+}
+
+// Expire drops buckets of granules starting
+// not after limit and returns their total count.
+func (w *window) Expire(limit int64) (expired int64) {
+	for i := range w.data {
+		b := &w.data[i]
+		if b.utc != noGranule && b.utc <= limit {
+			expired += b.cnt
+			*b = item{utc: noGranule}
+		}
 	}
-
-	return i
-}
-
-func (w *window) popFront() {
-	w.head = (w.head + 1) % len(w.data)
-	w.size--
-}
-
-func (w *window) round(t int64) int64 {
-	return t / w.gran * w.gran
+	return
 }
 
-func (w *window) idx(i int) int {
-	return (w.head + i) % len(w.data)
+func (w *window) slot(utc int64) int {
+	n := int64(len(w.data))
+	return int((utc/w.gran%n + n) % n)
 }